@@ -0,0 +1,84 @@
+// Package barcode encodes values as linear barcodes in the Code 128,
+// Code 39, EAN-13 and UPC-A symbologies.
+//
+// Values are validated for the symbology, including any check digit the
+// caller supplies, and an error is returned rather than a symbol that would
+// not scan.
+package barcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Symbology names accepted by Encode.
+const (
+	Code128 = "code128"
+	Code39  = "code39"
+	EAN13   = "ean13"
+	UPCA    = "upca"
+)
+
+// Code is an encoded barcode as a row of equally wide modules, without
+// quiet zones.
+type Code struct {
+	modules []bool
+	text    string
+}
+
+// Width returns the number of modules in the symbol.
+func (c *Code) Width() int { return len(c.modules) }
+
+// Dark reports whether module i is part of a bar.
+func (c *Code) Dark(i int) bool {
+	return i >= 0 && i < len(c.modules) && c.modules[i]
+}
+
+// Text returns the human-readable text printed under the symbol, including
+// a computed check digit.
+func (c *Code) Text() string { return c.text }
+
+// Encode encodes value in the named symbology. Names are case-insensitive
+// and "upc" is accepted for UPC-A.
+func Encode(symbology, value string) (*Code, error) {
+	var (
+		code *Code
+		err  error
+	)
+
+	switch strings.ToLower(strings.TrimSpace(symbology)) {
+	case Code128:
+		code, err = encodeCode128(value)
+	case Code39:
+		code, err = encodeCode39(value)
+	case EAN13:
+		code, err = encodeEAN13(value)
+	case UPCA, "upc":
+		code, err = encodeUPCA(value)
+	default:
+		return nil, fmt.Errorf("barcode: unknown type %q", symbology)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("barcode: invalid %s value %q: %w", symbology, value, err)
+	}
+	return code, nil
+}
+
+// appendWidths appends alternating bars and spaces of the given widths,
+// starting with a bar.
+func appendWidths(modules []bool, widths ...int) []bool {
+	for idx, width := range widths {
+		for i := 0; i < width; i++ {
+			modules = append(modules, idx%2 == 0)
+		}
+	}
+	return modules
+}
+
+// appendPattern appends a pattern of '1' (bar) and '0' (space) modules.
+func appendPattern(modules []bool, pattern string) []bool {
+	for _, module := range pattern {
+		modules = append(modules, module == '1')
+	}
+	return modules
+}
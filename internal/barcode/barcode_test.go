@@ -0,0 +1,173 @@
+package barcode
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// modulesString renders a symbol as '1' (bar) and '0' (space) modules.
+func modulesString(code *Code) string {
+	var builder strings.Builder
+	for i := 0; i < code.Width(); i++ {
+		if code.Dark(i) {
+			builder.WriteByte('1')
+		} else {
+			builder.WriteByte('0')
+		}
+	}
+	return builder.String()
+}
+
+func TestCode128PatternsAreWellFormed(t *testing.T) {
+	seen := map[string]bool{}
+	for value, pattern := range code128Patterns {
+		want := 11
+		if value == code128Stop {
+			want = 13
+		}
+
+		sum := 0
+		for _, width := range pattern {
+			sum += width
+		}
+		if sum != want {
+			t.Fatalf("pattern %d spans %d modules, want %d", value, sum, want)
+		}
+
+		signature := fmt.Sprint(pattern)
+		if seen[signature] {
+			t.Fatalf("pattern %d duplicates another value", value)
+		}
+		seen[signature] = true
+	}
+}
+
+func TestCode128Values(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []int
+	}{
+		// Start B, "A", "B", then checksum (104 + 33 + 2*34) % 103.
+		{"AB", []int{104, 33, 34, 102}},
+		// An even run of digits packs into code set C.
+		{"1234", []int{105, 12, 34, 82}},
+		// Four trailing digits switch from code set B to C.
+		{"SKU1234", []int{104, 51, 43, 53, 99, 12, 34, 30}},
+		// Three digits are not worth a switch.
+		{"A123", []int{104, 33, 17, 18, 19, 95}},
+	}
+
+	for _, tt := range tests {
+		got, err := code128Values(tt.value)
+		if err != nil {
+			t.Fatalf("code128Values(%q) returned error: %v", tt.value, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Fatalf("code128Values(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	if _, err := Encode(Code128, "café"); err == nil {
+		t.Fatal("expected error for non-ASCII value")
+	}
+	if _, err := Encode(Code128, ""); err == nil {
+		t.Fatal("expected error for empty value")
+	}
+}
+
+func TestCode39PatternsAreWellFormed(t *testing.T) {
+	for r, pattern := range code39Patterns {
+		if len(pattern) != 9 || strings.Count(pattern, "w") != 3 {
+			t.Fatalf("pattern for %q must have 9 elements with 3 wide, got %s", r, pattern)
+		}
+	}
+}
+
+func TestEncodeCode39(t *testing.T) {
+	code, err := Encode("CODE39", "A-1")
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+
+	// Five characters including the start and stop asterisks, each 15 modules
+	// wide with a narrow gap between them.
+	if got, want := code.Width(), 5*15+4; got != want {
+		t.Fatalf("expected %d modules, got %d", want, got)
+	}
+	if got := modulesString(code)[:15]; got != "100010111011101" {
+		t.Fatalf("unexpected start character %s", got)
+	}
+
+	if _, err := Encode(Code39, "abc"); err == nil {
+		t.Fatal("expected error for lower-case characters")
+	}
+	if _, err := Encode(Code39, "A*B"); err == nil {
+		t.Fatal("expected error for the start/stop character")
+	}
+}
+
+func TestEncodeEAN13(t *testing.T) {
+	code, err := Encode(EAN13, "400638133393")
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	if code.Text() != "4006381333931" {
+		t.Fatalf("expected check digit 1 to be added, got %s", code.Text())
+	}
+	if code.Width() != 95 {
+		t.Fatalf("expected 95 modules, got %d", code.Width())
+	}
+
+	modules := modulesString(code)
+	if modules[:3] != "101" || modules[45:50] != "01010" || modules[92:] != "101" {
+		t.Fatalf("unexpected guard patterns in %s", modules)
+	}
+	// With a leading 4 the second digit, 0, uses an L pattern and the
+	// third, 0, a G pattern.
+	if modules[3:10] != "0001101" || modules[10:17] != "0100111" {
+		t.Fatalf("unexpected left-hand patterns in %s", modules)
+	}
+	// The last digit, 1, uses the R pattern.
+	if modules[85:92] != "1100110" {
+		t.Fatalf("unexpected right-hand pattern in %s", modules)
+	}
+
+	if _, err := Encode(EAN13, "4006381333931"); err != nil {
+		t.Fatalf("expected valid check digit to be accepted: %v", err)
+	}
+	if _, err := Encode(EAN13, "4006381333932"); err == nil || !strings.Contains(err.Error(), "check digit") {
+		t.Fatalf("expected check digit error, got %v", err)
+	}
+	if _, err := Encode(EAN13, "40063813"); err == nil {
+		t.Fatal("expected length error")
+	}
+	if _, err := Encode(EAN13, "40063813339X"); err == nil {
+		t.Fatal("expected error for non-digits")
+	}
+}
+
+func TestEncodeUPCA(t *testing.T) {
+	code, err := Encode(UPCA, "03600029145")
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	if code.Text() != "036000291452" {
+		t.Fatalf("expected check digit 2 to be added, got %s", code.Text())
+	}
+
+	ean, err := Encode(EAN13, "0036000291452")
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	if modulesString(code) != modulesString(ean) {
+		t.Fatal("expected UPC-A to match the EAN-13 symbol with a leading zero")
+	}
+
+	if _, err := Encode("upc", "036000291453"); err == nil {
+		t.Fatal("expected check digit error")
+	}
+	if _, err := Encode("itf", "1234"); err == nil {
+		t.Fatal("expected error for unknown symbology")
+	}
+}
@@ -0,0 +1,123 @@
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// code128Patterns holds the bar and space widths of each Code 128 symbol
+// value, including the start codes (103-105) and the stop code (106).
+var code128Patterns = [107][]int{
+	{2, 1, 2, 2, 2, 2}, {2, 2, 2, 1, 2, 2}, {2, 2, 2, 2, 2, 1}, {1, 2, 1, 2, 2, 3}, {1, 2, 1, 3, 2, 2},
+	{1, 3, 1, 2, 2, 2}, {1, 2, 2, 2, 1, 3}, {1, 2, 2, 3, 1, 2}, {1, 3, 2, 2, 1, 2}, {2, 2, 1, 2, 1, 3},
+	{2, 2, 1, 3, 1, 2}, {2, 3, 1, 2, 1, 2}, {1, 1, 2, 2, 3, 2}, {1, 2, 2, 1, 3, 2}, {1, 2, 2, 2, 3, 1},
+	{1, 1, 3, 2, 2, 2}, {1, 2, 3, 1, 2, 2}, {1, 2, 3, 2, 2, 1}, {2, 2, 3, 2, 1, 1}, {2, 2, 1, 1, 3, 2},
+	{2, 2, 1, 2, 3, 1}, {2, 1, 3, 2, 1, 2}, {2, 2, 3, 1, 1, 2}, {3, 1, 2, 1, 3, 1}, {3, 1, 1, 2, 2, 2},
+	{3, 2, 1, 1, 2, 2}, {3, 2, 1, 2, 2, 1}, {3, 1, 2, 2, 1, 2}, {3, 2, 2, 1, 1, 2}, {3, 2, 2, 2, 1, 1},
+	{2, 1, 2, 1, 2, 3}, {2, 1, 2, 3, 2, 1}, {2, 3, 2, 1, 2, 1}, {1, 1, 1, 3, 2, 3}, {1, 3, 1, 1, 2, 3},
+	{1, 3, 1, 3, 2, 1}, {1, 1, 2, 3, 1, 3}, {1, 3, 2, 1, 1, 3}, {1, 3, 2, 3, 1, 1}, {2, 1, 1, 3, 1, 3},
+	{2, 3, 1, 1, 1, 3}, {2, 3, 1, 3, 1, 1}, {1, 1, 2, 1, 3, 3}, {1, 1, 2, 3, 3, 1}, {1, 3, 2, 1, 3, 1},
+	{1, 1, 3, 1, 2, 3}, {1, 1, 3, 3, 2, 1}, {1, 3, 3, 1, 2, 1}, {3, 1, 3, 1, 2, 1}, {2, 1, 1, 3, 3, 1},
+	{2, 3, 1, 1, 3, 1}, {2, 1, 3, 1, 1, 3}, {2, 1, 3, 3, 1, 1}, {2, 1, 3, 1, 3, 1}, {3, 1, 1, 1, 2, 3},
+	{3, 1, 1, 3, 2, 1}, {3, 3, 1, 1, 2, 1}, {3, 1, 2, 1, 1, 3}, {3, 1, 2, 3, 1, 1}, {3, 3, 2, 1, 1, 1},
+	{3, 1, 4, 1, 1, 1}, {2, 2, 1, 4, 1, 1}, {4, 3, 1, 1, 1, 1}, {1, 1, 1, 2, 2, 4}, {1, 1, 1, 4, 2, 2},
+	{1, 2, 1, 1, 2, 4}, {1, 2, 1, 4, 2, 1}, {1, 4, 1, 1, 2, 2}, {1, 4, 1, 2, 2, 1}, {1, 1, 2, 2, 1, 4},
+	{1, 1, 2, 4, 1, 2}, {1, 2, 2, 1, 1, 4}, {1, 2, 2, 4, 1, 1}, {1, 4, 2, 1, 1, 2}, {1, 4, 2, 2, 1, 1},
+	{2, 4, 1, 2, 1, 1}, {2, 2, 1, 1, 1, 4}, {4, 1, 3, 1, 1, 1}, {2, 4, 1, 1, 1, 2}, {1, 3, 4, 1, 1, 1},
+	{1, 1, 1, 2, 4, 2}, {1, 2, 1, 1, 4, 2}, {1, 2, 1, 2, 4, 1}, {1, 1, 4, 2, 1, 2}, {1, 2, 4, 1, 1, 2},
+	{1, 2, 4, 2, 1, 1}, {4, 1, 1, 2, 1, 2}, {4, 2, 1, 1, 1, 2}, {4, 2, 1, 2, 1, 1}, {2, 1, 2, 1, 4, 1},
+	{2, 1, 4, 1, 2, 1}, {4, 1, 2, 1, 2, 1}, {1, 1, 1, 1, 4, 3}, {1, 1, 1, 3, 4, 1}, {1, 3, 1, 1, 4, 1},
+	{1, 1, 4, 1, 1, 3}, {1, 1, 4, 3, 1, 1}, {4, 1, 1, 1, 1, 3}, {4, 1, 1, 3, 1, 1}, {1, 1, 3, 1, 4, 1},
+	{1, 1, 4, 1, 3, 1}, {3, 1, 1, 1, 4, 1}, {4, 1, 1, 1, 3, 1}, {2, 1, 1, 4, 1, 2}, {2, 1, 1, 2, 1, 4},
+	{2, 1, 1, 2, 3, 2}, {2, 3, 3, 1, 1, 1, 2},
+}
+
+const (
+	code128CodeB  = 100
+	code128CodeC  = 99
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// code128Values returns the symbol values for value, from the start code to
+// the checksum. Runs of digits that are long enough to save symbols use code
+// set C, which packs two digits per symbol; everything else uses code set B,
+// which covers printable ASCII.
+func code128Values(value string) ([]int, error) {
+	if value == "" {
+		return nil, errors.New("value is empty")
+	}
+	for _, r := range value {
+		if r < 32 || r > 126 {
+			return nil, errors.New("only printable ASCII characters are supported")
+		}
+	}
+
+	var values []int
+	codeSet := 0
+	for i := 0; i < len(value); {
+		digits := len(value[i:]) - len(strings.TrimLeft(value[i:], "0123456789"))
+		if digits%2 == 0 && code128PrefersCodeC(i, digits, len(value)) {
+			if codeSet != code128CodeC {
+				values = append(values, code128Switch(codeSet, code128CodeC, code128StartC))
+				codeSet = code128CodeC
+			}
+			for end := i + digits; i < end; i += 2 {
+				values = append(values, int(value[i]-'0')*10+int(value[i+1]-'0'))
+			}
+			continue
+		}
+
+		// An odd digit run leaves its first digit in code set B, so the rest
+		// of the run can be packed on the next pass.
+		if codeSet != code128CodeB {
+			values = append(values, code128Switch(codeSet, code128CodeB, code128StartB))
+			codeSet = code128CodeB
+		}
+		values = append(values, int(value[i])-32)
+		i++
+	}
+
+	checksum := values[0]
+	for idx, symbol := range values[1:] {
+		checksum += (idx + 1) * symbol
+	}
+	return append(values, checksum%103), nil
+}
+
+// code128PrefersCodeC reports whether a run of digits starting at start is
+// long enough to be worth the code set switches around it: the whole value,
+// four digits at either end or six in the middle.
+func code128PrefersCodeC(start, digits, length int) bool {
+	switch {
+	case digits == length:
+		return digits >= 2
+	case start == 0 || start+digits == length:
+		return digits >= 4
+	default:
+		return digits >= 6
+	}
+}
+
+// code128Switch returns the start code for the first code set, or the code
+// that switches to it afterwards.
+func code128Switch(current, next, start int) int {
+	if current == 0 {
+		return start
+	}
+	return next
+}
+
+func encodeCode128(value string) (*Code, error) {
+	values, err := code128Values(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []bool
+	for _, symbol := range append(values, code128Stop) {
+		modules = appendWidths(modules, code128Patterns[symbol]...)
+	}
+	return &Code{modules: modules, text: value}, nil
+}
@@ -0,0 +1,53 @@
+package barcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// code39Patterns holds the narrow (n) and wide (w) elements of each Code 39
+// character, alternating bar and space and starting with a bar.
+var code39Patterns = map[rune]string{
+	'0': "nnnwwnwnn", '1': "wnnwnnnnw", '2': "nnwwnnnnw", '3': "wnwwnnnnn",
+	'4': "nnnwwnnnw", '5': "wnnwwnnnn", '6': "nnwwwnnnn", '7': "nnnwnnwnw",
+	'8': "wnnwnnwnn", '9': "nnwwnnwnn", 'A': "wnnnnwnnw", 'B': "nnwnnwnnw",
+	'C': "wnwnnwnnn", 'D': "nnnnwwnnw", 'E': "wnnnwwnnn", 'F': "nnwnwwnnn",
+	'G': "nnnnnwwnw", 'H': "wnnnnwwnn", 'I': "nnwnnwwnn", 'J': "nnnnwwwnn",
+	'K': "wnnnnnnww", 'L': "nnwnnnnww", 'M': "wnwnnnnwn", 'N': "nnnnwnnww",
+	'O': "wnnnwnnwn", 'P': "nnwnwnnwn", 'Q': "nnnnnnwww", 'R': "wnnnnnwwn",
+	'S': "nnwnnnwwn", 'T': "nnnnwnwwn", 'U': "wwnnnnnnw", 'V': "nwwnnnnnw",
+	'W': "wwwnnnnnn", 'X': "nwnnwnnnw", 'Y': "wwnnwnnnn", 'Z': "nwwnwnnnn",
+	'-': "nwnnnnwnw", '.': "wwnnnnwnn", ' ': "nwwnnnwnn", '$': "nwnwnwnnn",
+	'/': "nwnwnnnwn", '+': "nwnnnwnwn", '%': "nnnwnwnwn", '*': "nwnnwnwnn",
+}
+
+// code39WideRatio is the width of wide elements in narrow modules.
+const code39WideRatio = 3
+
+func encodeCode39(value string) (*Code, error) {
+	if value == "" {
+		return nil, errors.New("value is empty")
+	}
+	for _, r := range value {
+		if _, ok := code39Patterns[r]; !ok || r == '*' {
+			return nil, fmt.Errorf("character %q is not in the Code 39 set (0-9, A-Z, space, - . $ / + %%)", r)
+		}
+	}
+
+	var modules []bool
+	for idx, r := range "*" + value + "*" {
+		if idx > 0 {
+			modules = append(modules, false)
+		}
+		for element, width := range code39Patterns[r] {
+			size := 1
+			if width == 'w' {
+				size = code39WideRatio
+			}
+			for i := 0; i < size; i++ {
+				modules = append(modules, element%2 == 0)
+			}
+		}
+	}
+	return &Code{modules: modules, text: value}, nil
+}
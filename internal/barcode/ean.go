@@ -0,0 +1,116 @@
+package barcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// eanLeftOdd holds the left-hand L patterns for each digit. Left-hand G
+// patterns and right-hand patterns are derived from them.
+var eanLeftOdd = [10]string{
+	"0001101", "0011001", "0010011", "0111101", "0100011",
+	"0110001", "0101111", "0111011", "0110111", "0001011",
+}
+
+// eanParity selects L or G patterns for the six left-hand digits, keyed by
+// the leading digit of an EAN-13 number.
+var eanParity = [10]string{
+	"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG",
+	"LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL",
+}
+
+// encodeEAN13 encodes 12 digits, adding the check digit, or 13 digits whose
+// check digit must be correct.
+func encodeEAN13(value string) (*Code, error) {
+	digits, err := withCheckDigit(value, 12)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := appendPattern(nil, "101")
+	parity := eanParity[digits[0]-'0']
+	for i := 1; i <= 6; i++ {
+		pattern := eanLeftOdd[digits[i]-'0']
+		if parity[i-1] == 'G' {
+			pattern = reversed(inverted(pattern))
+		}
+		modules = appendPattern(modules, pattern)
+	}
+	modules = appendPattern(modules, "01010")
+	for i := 7; i <= 12; i++ {
+		modules = appendPattern(modules, inverted(eanLeftOdd[digits[i]-'0']))
+	}
+	modules = appendPattern(modules, "101")
+
+	return &Code{modules: modules, text: digits}, nil
+}
+
+// encodeUPCA encodes 11 digits, adding the check digit, or 12 digits whose
+// check digit must be correct. UPC-A is EAN-13 with a leading zero.
+func encodeUPCA(value string) (*Code, error) {
+	digits, err := withCheckDigit(value, 11)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := encodeEAN13("0" + digits)
+	if err != nil {
+		return nil, err
+	}
+	code.text = digits
+	return code, nil
+}
+
+// withCheckDigit validates a number of length digits, or length+1 digits
+// including a check digit, and returns it with the check digit.
+func withCheckDigit(value string, length int) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.Trim(value, "0123456789") != "" {
+		return "", errors.New("only digits are allowed")
+	}
+
+	switch len(value) {
+	case length:
+		return value + string(rune('0'+checkDigit(value))), nil
+	case length + 1:
+		want := checkDigit(value[:length])
+		if got := int(value[length] - '0'); got != want {
+			return "", fmt.Errorf("check digit is %d, expected %d", got, want)
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("expected %d digits, or %d with check digit, got %d", length, length+1, len(value))
+	}
+}
+
+// checkDigit computes the GS1 modulo 10 check digit: digits are weighted 3
+// and 1 alternately from the rightmost.
+func checkDigit(digits string) int {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		weight := 1
+		if (len(digits)-1-i)%2 == 0 {
+			weight = 3
+		}
+		sum += int(digits[i]-'0') * weight
+	}
+	return (10 - sum%10) % 10
+}
+
+func inverted(pattern string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '0' {
+			return '1'
+		}
+		return '0'
+	}, pattern)
+}
+
+func reversed(pattern string) string {
+	runes := []rune(pattern)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
@@ -0,0 +1,123 @@
+// Package engine provides barcode rendering functionality.
+package engine
+
+import (
+	"github.com/dannyswat/reportgo/internal/barcode"
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+const (
+	defaultBarcodeWidth  = 50.0
+	defaultBarcodeHeight = 15.0
+
+	// barcodeTextHeight is the height of the human-readable line under the
+	// bars.
+	barcodeTextHeight = 4.0
+
+	// defaultBarcodeQuietZone is the width of the light margin on either
+	// side of the bars, in modules, when none is set.
+	defaultBarcodeQuietZone = 10
+)
+
+// renderBarcode encodes the element value and draws the bars with the
+// optional human-readable text under them. It is positioned like an image
+// and advances Y past the element.
+func (e *Engine) renderBarcode(bc *models.Barcode) error {
+	code, err := barcode.Encode(bc.Type, e.processTemplate(bc.Value))
+	if err != nil {
+		return err
+	}
+
+	width, height := barcodeSize(bc)
+
+	x := bc.X
+	y := bc.Y
+	if x == 0 {
+		x, _ = e.pdf.GetXY()
+	}
+
+	if bc.Align == "C" {
+		x = e.flowLeftMargin() + (e.flowContentWidth()-width)/2
+	} else if bc.Align == "R" {
+		pageWidth, _ := e.pdf.GetPageSize()
+		x = pageWidth - e.flowRightMargin() - width
+	} else if bc.X == 0 {
+		x = e.flowLeftMargin()
+	}
+
+	if y == 0 {
+		y = e.pdf.GetY()
+	} else {
+		_, pageHeight := e.pdf.GetPageSize()
+		y = e.resolvePositionedY(y, e.pdf.GetY(), pageHeight)
+	}
+
+	e.drawBarcode(code, bc, x, y, width, height)
+
+	e.pdf.SetY(y + height)
+	if bc.SpacingAfter > 0 {
+		e.pdf.Ln(bc.SpacingAfter)
+	}
+
+	return nil
+}
+
+// barcodeSize returns the width, quiet zones included, and total height of a
+// barcode element.
+func barcodeSize(bc *models.Barcode) (float64, float64) {
+	width := bc.Width
+	if width <= 0 {
+		width = defaultBarcodeWidth
+	}
+	height := bc.Height
+	if height <= 0 {
+		height = defaultBarcodeHeight
+	}
+	return width, height
+}
+
+// drawBarcode fills the quiet zones and each bar of code across width and
+// draws the human-readable text in the bottom line when it is shown.
+func (e *Engine) drawBarcode(code *barcode.Code, bc *models.Barcode, x, y, width, height float64) {
+	showText := boolValue(bc.ShowText, true)
+	barHeight := height
+	if showText {
+		barHeight -= barcodeTextHeight
+	}
+	quietZone := max(intValue(bc.QuietZone, defaultBarcodeQuietZone), 0)
+	module := width / float64(code.Width()+2*quietZone)
+	barsX := x + float64(quietZone)*module
+
+	e.withGraphicsState(func() {
+		if barHeight > 0 {
+			if quietZone > 0 {
+				e.pdf.SetFillColor(quietZoneColor.ToRGB())
+				e.pdf.Rect(x, y, width, barHeight, "F")
+			}
+
+			e.pdf.SetFillColor(graphicColor(bc.Color, defaultSymbolColor))
+			for i := 0; i < code.Width(); {
+				if !code.Dark(i) {
+					i++
+					continue
+				}
+
+				start := i
+				for i < code.Width() && code.Dark(i) {
+					i++
+				}
+				e.pdf.Rect(barsX+float64(start)*module, y, float64(i-start)*module, barHeight, "F")
+			}
+		}
+
+		if showText {
+			if bc.Style != "" {
+				e.applyStyle(bc.Style)
+			} else {
+				e.pdf.SetFontSize(8)
+			}
+			e.pdf.SetXY(x, y+height-barcodeTextHeight)
+			e.pdf.CellFormat(width, barcodeTextHeight, code.Text(), "", 0, "C", false, 0, "")
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+func TestRenderBarcodeDrawsBarsAndText(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"SKU": "SKU-1042"})
+
+	startY := engine.pdf.GetY()
+	err := engine.renderBarcode(&models.Barcode{
+		Type:   "code128",
+		Value:  "{{.SKU}}",
+		Width:  60,
+		Height: 20,
+		Color:  "#FF0000",
+	})
+	if err != nil {
+		t.Fatalf("renderBarcode returned error: %v", err)
+	}
+
+	if got, want := engine.pdf.GetY(), startY+20; got != want {
+		t.Fatalf("expected Y %.2f after the barcode, got %.2f", want, got)
+	}
+	output := renderedPDF(t, engine)
+	if !strings.Contains(output, "1.000 0.000 0.000 rg") {
+		t.Fatalf("expected bar fill color in output")
+	}
+	if !strings.Contains(output, "(SKU-1042)") {
+		t.Fatalf("expected human-readable text under the bars")
+	}
+}
+
+func TestRenderBarcodeShowsCheckDigitAndCanHideText(t *testing.T) {
+	engine := newTestEngine(t, nil)
+
+	if err := engine.renderBarcode(&models.Barcode{Type: "ean13", Value: "400638133393"}); err != nil {
+		t.Fatalf("renderBarcode returned error: %v", err)
+	}
+	hidden := false
+	if err := engine.renderBarcode(&models.Barcode{Type: "upca", Value: "03600029145", ShowText: &hidden}); err != nil {
+		t.Fatalf("renderBarcode returned error: %v", err)
+	}
+
+	output := renderedPDF(t, engine)
+	if !strings.Contains(output, "(4006381333931)") {
+		t.Fatalf("expected EAN-13 text with its check digit")
+	}
+	if strings.Contains(output, "(036000291452)") {
+		t.Fatalf("expected UPC-A text to be hidden")
+	}
+}
+
+func TestRenderBarcodeReservesQuietZones(t *testing.T) {
+	// EAN-13 bars span 95 modules, so a 115mm barcode with the default
+	// quiet zones has 1mm modules and starts its first bar 10mm in.
+	hidden := false
+	engine := newTestEngine(t, nil)
+	err := engine.renderBarcode(&models.Barcode{Type: "ean13", Value: "400638133393", Width: 115, Height: 10, ShowText: &hidden})
+	if err != nil {
+		t.Fatalf("renderBarcode returned error: %v", err)
+	}
+	output := renderedPDF(t, engine)
+	if !strings.Contains(output, "1.000 g\n28.35 813.54 325.98 -28.35 re f") {
+		t.Fatalf("expected the quiet zones to be filled white across the whole width")
+	}
+	if !strings.Contains(output, "0.000 g\n56.69 813.54 2.83 -28.35 re f") {
+		t.Fatalf("expected the first bar 10 modules inside the quiet zone")
+	}
+
+	none := 0
+	engine = newTestEngine(t, nil)
+	err = engine.renderBarcode(&models.Barcode{Type: "ean13", Value: "400638133393", Width: 95, Height: 10, ShowText: &hidden, QuietZone: &none})
+	if err != nil {
+		t.Fatalf("renderBarcode returned error: %v", err)
+	}
+	output = renderedPDF(t, engine)
+	if strings.Contains(output, "1.000 g") || !strings.Contains(output, "0.000 g\n28.35 813.54 2.83 -28.35 re f") {
+		t.Fatalf("expected quietZone=\"0\" to start the bars at the element origin")
+	}
+}
+
+func TestRenderBarcodeRejectsInvalidValues(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"EAN": "4006381333932"})
+
+	err := engine.renderElement(models.SectionElement{Type: "barcode", Barcode: &models.Barcode{Type: "ean13", Value: "{{.EAN}}"}})
+	if err == nil || !strings.Contains(err.Error(), "check digit") {
+		t.Fatalf("expected check digit error, got %v", err)
+	}
+
+	err = engine.renderRow(&models.Row{Elements: []models.SectionElement{
+		{Type: "barcode", Barcode: &models.Barcode{Type: "code39", Value: "lower"}},
+	}})
+	if err == nil {
+		t.Fatal("expected row to report the invalid Code 39 value")
+	}
+}
@@ -0,0 +1,62 @@
+// Package engine provides PDF outline functionality.
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+// bookmarkSection adds the outline entries for a section at the current
+// position. A looped section gets one entry, on its first item, with an entry
+// nested under it for every item.
+func (e *Engine) bookmarkSection(section *models.Section, first bool, item int) {
+	if !boolValue(section.Bookmark, true) {
+		e.headingBookmarkLevel = 0
+		return
+	}
+
+	if section.Loop == "" {
+		e.addBookmark(e.processTemplate(sectionTitle(section)), 0, -1)
+		e.headingBookmarkLevel = 1
+		return
+	}
+
+	if first {
+		e.addBookmark(section.Name, 0, -1)
+	}
+	title := fmt.Sprintf("%s %d", section.Name, item)
+	if section.Title != "" {
+		title = e.processTemplate(section.Title)
+	}
+	e.addBookmark(title, 1, -1)
+	e.headingBookmarkLevel = 2
+}
+
+// sectionTitle returns the section title template, or its name.
+func sectionTitle(section *models.Section) string {
+	if section.Title != "" {
+		return section.Title
+	}
+	return section.Name
+}
+
+// addBookmark adds an outline entry on the current page at y, or at the
+// current position when y is -1. The level is limited to one below the
+// previous entry because gofpdf cannot skip outline levels.
+func (e *Engine) addBookmark(title string, level int, y float64) {
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		return
+	}
+
+	if level > e.bookmarkLevel+1 {
+		level = e.bookmarkLevel + 1
+	}
+	if level < 0 {
+		level = 0
+	}
+	e.pdf.Bookmark(title, level, y)
+	e.bookmarkLevel = level
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+func TestRenderSectionsAddsBookmarks(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Regions": []interface{}{"North", "South"}})
+	hidden := false
+	engine.report.Sections.Sections = []models.Section{
+		{
+			Name:     "cover",
+			Bookmark: &hidden,
+			Elements: []models.SectionElement{{Type: "text", Text: &models.Text{Content: "Cover"}}},
+		},
+		{
+			Name:  "summary",
+			Title: "Executive Summary",
+			Elements: []models.SectionElement{
+				{Type: "text", Text: &models.Text{Content: "Key Findings", TocEntry: "true"}},
+			},
+		},
+		{
+			Name:  "Regions",
+			Loop:  "{{.Regions}}",
+			Title: "Region {{.item}}",
+			Elements: []models.SectionElement{
+				{Type: "text", Text: &models.Text{Content: "{{.item}}"}},
+			},
+		},
+		{
+			Name:     "numbered",
+			Loop:     "{{.Regions}}",
+			Elements: []models.SectionElement{{Type: "spacer", Spacer: &models.Spacer{Height: 5}}},
+		},
+	}
+
+	if err := engine.renderSections(); err != nil {
+		t.Fatalf("renderSections returned error: %v", err)
+	}
+
+	output := renderedPDF(t, engine)
+	titles := regexp.MustCompile(`/Title \(([^)]*)\)`).FindAllStringSubmatch(output, -1)
+	var got []string
+	for _, title := range titles {
+		got = append(got, title[1])
+	}
+	want := []string{"Executive Summary", "Key Findings", "Regions", "Region North", "Region South", "numbered", "numbered 1", "numbered 2"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected bookmarks %q, got %q", want, got)
+	}
+	if !strings.Contains(output, "/Type /Outlines") {
+		t.Fatalf("expected an outline in output")
+	}
+}
+
+func TestAddBookmarkLimitsLevelJumps(t *testing.T) {
+	engine := newTestEngine(t, nil)
+
+	engine.addBookmark("First", 2, -1)
+	if engine.bookmarkLevel != 0 {
+		t.Fatalf("expected first bookmark at level 0, got %d", engine.bookmarkLevel)
+	}
+	engine.addBookmark("Deep", 3, -1)
+	if engine.bookmarkLevel != 1 {
+		t.Fatalf("expected bookmark one level below the previous, got %d", engine.bookmarkLevel)
+	}
+	engine.addBookmark("  ", 0, -1)
+	if engine.bookmarkLevel != 1 {
+		t.Fatalf("expected blank bookmark to be skipped")
+	}
+}
@@ -0,0 +1,117 @@
+// Package engine provides box container rendering functionality.
+package engine
+
+import (
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+// boxFragment is the part of a box background on one page.
+type boxFragment struct {
+	box   *models.Box
+	page  int
+	x, y  float64
+	w, h  float64
+	drawn bool
+}
+
+// renderBox draws a box across the flow width with its children inside the
+// padding. The children are laid out first on scratch pages to size the
+// background, which is drawn behind them: on the first page now and on each
+// later page as it starts. Every page gets a complete box.
+func (e *Engine) renderBox(box *models.Box) error {
+	if box == nil {
+		return nil
+	}
+
+	x := e.flowLeftMargin()
+	width := e.flowContentWidth()
+	renderChildren := func() error {
+		e.pdf.SetXY(x+box.Padding, e.pdf.GetY()+box.Padding)
+		return e.withFlowBounds(box.Padding, box.Padding, func() error {
+			return e.renderElements(box.Elements)
+		})
+	}
+
+	if !e.measuring {
+		pageEnds, err := e.measureLayout(renderChildren, true)
+		if err != nil {
+			return err
+		}
+		if len(pageEnds) > 1 && pageEnds[0] <= e.pdf.GetY()+box.Padding {
+			// Only the padding fits on this page.
+			e.pdf.AddPage()
+			if pageEnds, err = e.measureLayout(renderChildren, true); err != nil {
+				return err
+			}
+		}
+
+		_, top, _, _ := e.pdf.GetMargins()
+		page, y := e.pdf.PageNo(), e.pdf.GetY()
+		for idx := range pageEnds {
+			bottom := e.pageBreakTrigger()
+			if idx == len(pageEnds)-1 {
+				bottom = pageEnds[idx] + box.Padding
+			}
+			e.boxFragments = append(e.boxFragments, boxFragment{box: box, page: page + idx, x: x, y: y, w: width, h: bottom - y})
+			y = top
+		}
+		e.drawBoxFragments()
+	}
+	e.defineAnchor(box.Anchor)
+
+	if err := renderChildren(); err != nil {
+		return err
+	}
+
+	e.pdf.SetXY(x, e.pdf.GetY()+box.Padding)
+	if box.SpacingAfter > 0 {
+		e.pdf.Ln(box.SpacingAfter)
+	}
+	return nil
+}
+
+// drawBoxFragments draws the box backgrounds waiting for the current page.
+func (e *Engine) drawBoxFragments() {
+	page := e.pdf.PageNo()
+	pending := e.boxFragments[:0]
+	for _, fragment := range e.boxFragments {
+		if fragment.page != page {
+			if fragment.page > page {
+				pending = append(pending, fragment)
+			}
+			continue
+		}
+		e.withGraphicsState(func() {
+			e.drawBoxBackground(fragment)
+		})
+	}
+	e.boxFragments = pending
+}
+
+// drawBoxBackground fills and outlines one box fragment.
+func (e *Engine) drawBoxBackground(fragment boxFragment) {
+	box := fragment.box
+	style := ""
+	if box.FillColor != nil {
+		r, g, b := box.FillColor.ToRGB()
+		e.pdf.SetFillColor(r, g, b)
+		style = "F"
+	}
+	if box.BorderColor != nil || box.BorderWidth > 0 {
+		r, g, b := box.BorderColor.ToRGB()
+		e.pdf.SetDrawColor(r, g, b)
+		style += "D"
+	}
+	if style == "" || fragment.h <= 0 {
+		return
+	}
+	if box.BorderWidth > 0 {
+		e.pdf.SetLineWidth(box.BorderWidth)
+	}
+
+	if box.Radius > 0 {
+		e.pdf.RoundedRect(fragment.x, fragment.y, fragment.w, fragment.h, box.Radius, "1234", style)
+	} else {
+		e.pdf.Rect(fragment.x, fragment.y, fragment.w, fragment.h, style)
+	}
+}
@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+func boxTestTexts(count int) []models.SectionElement {
+	var elements []models.SectionElement
+	for idx := 0; idx < count; idx++ {
+		elements = append(elements, models.SectionElement{Type: "text", Text: &models.Text{Style: "body", Content: "Callout", Wrap: true}})
+	}
+	return elements
+}
+
+func TestRenderBoxSizesBackgroundToContent(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	box := &models.Box{
+		Padding:   4,
+		FillColor: &models.RGBColor{R: 255, G: 240, B: 200},
+		Elements:  boxTestTexts(2),
+	}
+	y := engine.pdf.GetY()
+	contentHeight, err := engine.measureHeight(func() error { return engine.renderElements(box.Elements) })
+	if err != nil {
+		t.Fatalf("measureHeight returned error: %v", err)
+	}
+
+	if err := engine.renderElement(models.SectionElement{Type: "box", Box: box}); err != nil {
+		t.Fatalf("renderElement returned error: %v", err)
+	}
+
+	if math.Abs(engine.pdf.GetY()-(y+contentHeight+8)) > 0.001 {
+		t.Fatalf("expected the box to end at %v, got %v", y+contentHeight+8, engine.pdf.GetY())
+	}
+	output := renderedPDF(t, engine)
+	background := strings.Index(output, " re f")
+	if background < 0 || background > strings.Index(output, "(Callout)") {
+		t.Fatalf("expected the background to be drawn before the content")
+	}
+	if x := textPosition(t, output, "Callout"); x <= (10+4)*72/25.4 {
+		t.Fatalf("expected the content inside the padding, got x %v", x)
+	}
+}
+
+func TestRenderBoxBreaksAcrossPages(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	engine.setupHeaderFooter()
+	engine.pdf.SetY(engine.pageBreakTrigger() - 20)
+	box := &models.Box{
+		Padding:     2,
+		BorderColor: &models.RGBColor{R: 0, G: 0, B: 0},
+		Elements:    boxTestTexts(8),
+	}
+
+	if err := engine.renderElement(models.SectionElement{Type: "box", Box: box}); err != nil {
+		t.Fatalf("renderElement returned error: %v", err)
+	}
+
+	if engine.pdf.PageNo() != 2 {
+		t.Fatalf("expected the box to continue on page 2, got page %d", engine.pdf.PageNo())
+	}
+	if len(engine.boxFragments) != 0 {
+		t.Fatalf("expected every fragment to be drawn, %d left", len(engine.boxFragments))
+	}
+	if count := strings.Count(renderedPDF(t, engine), " re S"); count != 2 {
+		t.Fatalf("expected a border on each page, got %d", count)
+	}
+}
+
+func TestRenderBoxMovesWhenOnlyPaddingFits(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	engine.setupHeaderFooter()
+	engine.pdf.SetY(engine.pageBreakTrigger() - 6)
+	box := &models.Box{
+		Padding:     4,
+		BorderColor: &models.RGBColor{R: 0, G: 0, B: 0},
+		Elements:    boxTestTexts(1),
+	}
+
+	if err := engine.renderElement(models.SectionElement{Type: "box", Box: box}); err != nil {
+		t.Fatalf("renderElement returned error: %v", err)
+	}
+
+	if engine.pdf.PageNo() != 2 {
+		t.Fatalf("expected the box on page 2, got page %d", engine.pdf.PageNo())
+	}
+	if count := strings.Count(renderedPDF(t, engine), " re S"); count != 1 {
+		t.Fatalf("expected one border on page 2, got %d", count)
+	}
+}
@@ -0,0 +1,541 @@
+// Package engine provides chart rendering functionality.
+package engine
+
+import (
+	"math"
+	"strings"
+
+	"github.com/dannyswat/reportgo/internal/models"
+	"github.com/phpdave11/gofpdf"
+)
+
+const (
+	// defaultChartHeight is the chart height when none is set.
+	defaultChartHeight = 60.0
+
+	// chartLabelHeight is the line height of titles, labels and legends.
+	chartLabelHeight = 5.0
+
+	// chartTickCount is the target number of value axis intervals.
+	chartTickCount = 5
+
+	// maxChartTicks caps the value axis labels for scales whose step is lost
+	// in the precision of very large values.
+	maxChartTicks = 20
+)
+
+// defaultChartPalette colors series and pie slices when the chart names no
+// styles, or fewer styles than it needs.
+var defaultChartPalette = []models.RGBColor{
+	{R: 51, G: 102, B: 153},
+	{R: 230, G: 126, B: 34},
+	{R: 76, G: 153, B: 76},
+	{R: 192, G: 57, B: 43},
+	{R: 125, G: 100, B: 170},
+	{R: 140, G: 110, B: 80},
+	{R: 214, G: 122, B: 177},
+	{R: 127, G: 127, B: 127},
+}
+
+// chartSeriesData is a resolved chart series with one value per category.
+type chartSeriesData struct {
+	label  string
+	color  models.RGBColor
+	values []float64
+}
+
+// chartData holds the category labels and series values of a chart, with
+// the palette used for series and pie slices.
+type chartData struct {
+	labels  []string
+	series  []chartSeriesData
+	palette []models.RGBColor
+}
+
+// chartArea is a rectangle in page coordinates.
+type chartArea struct {
+	x, y, width, height float64
+}
+
+// renderChart draws a chart inside the current flow bounds. Charts never
+// split across pages; one that does not fit starts a new page, unless it is
+// a row or rowgrid child, where the container handles pagination.
+func (e *Engine) renderChart(chart *models.Chart) {
+	width := chart.Width
+	if flowWidth := e.flowContentWidth(); width <= 0 || width > flowWidth {
+		width = flowWidth
+	}
+	height := chart.Height
+	if height <= 0 {
+		height = defaultChartHeight
+	}
+
+	if !e.inRow && !e.fitsOnPage(height) {
+		e.pdf.AddPage()
+	}
+	e.defineAnchor(chart.Anchor)
+
+	x := e.flowLeftMargin()
+	y := e.pdf.GetY()
+
+	// The chart is drawn at fixed positions, so its labels must not break
+	// the page.
+	autoPageBreak, bottomMargin := e.pdf.GetAutoPageBreak()
+	e.pdf.SetAutoPageBreak(false, bottomMargin)
+	defer e.pdf.SetAutoPageBreak(autoPageBreak, bottomMargin)
+
+	e.withGraphicsState(func() {
+		if chart.Style != "" {
+			e.applyStyle(chart.Style)
+		} else {
+			e.pdf.SetFontSize(8)
+		}
+
+		chartType := strings.ToLower(strings.TrimSpace(chart.Type))
+		data := e.resolveChartData(chart)
+		area := chartArea{x: x, y: y, width: width, height: height}
+
+		if title := e.processTemplate(chart.Title); title != "" {
+			e.pdf.SetXY(area.x, area.y)
+			e.pdf.CellFormat(area.width, chartLabelHeight, title, "", 0, "C", false, 0, "")
+			area.y += chartLabelHeight
+			area.height -= chartLabelHeight
+		}
+
+		if boolValue(chart.Legend, chartType == "pie" || len(data.series) > 1) {
+			area.height -= e.drawChartLegend(chartType, data, area)
+		}
+
+		if chartType == "pie" {
+			e.drawPieChart(data, area)
+		} else {
+			e.drawAxisChart(chart, chartType, data, area)
+		}
+	})
+
+	e.pdf.SetXY(e.flowLeftMargin(), y+height)
+	if chart.SpacingAfter > 0 {
+		e.pdf.Ln(chart.SpacingAfter)
+	}
+}
+
+// withGraphicsState runs fn and restores the font, colors and line width it
+// changes.
+func (e *Engine) withGraphicsState(fn func()) {
+	fontFamily, fontStyle := e.fontFamily, e.fontStyle
+	fontSize, _ := e.pdf.GetFontSize()
+	textR, textG, textB := e.pdf.GetTextColor()
+	fillR, fillG, fillB := e.pdf.GetFillColor()
+	drawR, drawG, drawB := e.pdf.GetDrawColor()
+	lineWidth := e.pdf.GetLineWidth()
+	defer func() {
+		if fontFamily != "" {
+			e.setFont(fontFamily, fontStyle, fontSize)
+		} else {
+			e.pdf.SetFontSize(fontSize)
+		}
+		e.pdf.SetTextColor(textR, textG, textB)
+		e.pdf.SetFillColor(fillR, fillG, fillB)
+		e.pdf.SetDrawColor(drawR, drawG, drawB)
+		e.pdf.SetLineWidth(lineWidth)
+	}()
+
+	fn()
+}
+
+// resolveChartData reads the category labels and series values from the
+// chart data source. Values that are not numeric or finite count as zero.
+func (e *Engine) resolveChartData(chart *models.Chart) chartData {
+	items := e.applyDataQuery(e.resolveDataItems(chart.DataSource), chart.DataQuery, "item")
+	palette := e.chartPalette(chart)
+
+	series := chart.Series
+	if len(series) == 0 {
+		series = []models.ChartSeries{{Field: chart.ValueField, Label: chart.YAxisTitle}}
+	}
+
+	data := chartData{labels: make([]string, len(items)), palette: palette}
+	for idx, item := range items {
+		label, _ := resolveDataPath(item, chart.LabelField)
+		data.labels[idx] = formatValue(label, "")
+	}
+
+	for idx, definition := range series {
+		resolved := chartSeriesData{
+			label:  e.processTemplate(definition.Label),
+			color:  palette[idx%len(palette)],
+			values: make([]float64, len(items)),
+		}
+		if resolved.label == "" {
+			resolved.label = definition.Field
+		}
+		if style, ok := e.styles[definition.Style]; ok && style.FillColor != nil {
+			resolved.color = *style.FillColor
+		}
+		for itemIdx, item := range items {
+			value, _ := resolveDataPath(item, definition.Field)
+			if number, ok := toFloat64(value); ok && !math.IsNaN(number) && !math.IsInf(number, 0) {
+				resolved.values[itemIdx] = number
+			}
+		}
+		data.series = append(data.series, resolved)
+	}
+
+	return data
+}
+
+// chartPalette returns the fill colors of the chart's named styles followed
+// by the default palette.
+func (e *Engine) chartPalette(chart *models.Chart) []models.RGBColor {
+	var palette []models.RGBColor
+	for _, name := range strings.Split(chart.Styles, ",") {
+		if style, ok := e.styles[strings.TrimSpace(name)]; ok && style.FillColor != nil {
+			palette = append(palette, *style.FillColor)
+		}
+	}
+	return append(palette, defaultChartPalette...)
+}
+
+// drawChartLegend draws the legend along the bottom of the area and returns
+// the height it takes. Pie charts list their slices, other charts their
+// series. Entries wrap onto further lines when they do not fit.
+func (e *Engine) drawChartLegend(chartType string, data chartData, area chartArea) float64 {
+	type legendEntry struct {
+		label string
+		color models.RGBColor
+		width float64
+	}
+
+	const swatch = 3.0
+	var entries []legendEntry
+	add := func(label string, color models.RGBColor) {
+		entries = append(entries, legendEntry{label, color, swatch + 1 + e.pdf.GetStringWidth(label) + 4})
+	}
+	if chartType == "pie" && len(data.series) > 0 {
+		palette := chartSliceColors(data)
+		for idx, label := range data.labels {
+			add(label, palette[idx])
+		}
+	} else {
+		for _, series := range data.series {
+			add(series.label, series.color)
+		}
+	}
+	if len(entries) == 0 {
+		return 0
+	}
+
+	var lines [][]legendEntry
+	lineWidth := 0.0
+	for _, entry := range entries {
+		if len(lines) == 0 || lineWidth+entry.width > area.width {
+			lines = append(lines, nil)
+			lineWidth = 0
+		}
+		lines[len(lines)-1] = append(lines[len(lines)-1], entry)
+		lineWidth += entry.width
+	}
+
+	height := float64(len(lines)) * chartLabelHeight
+	y := area.y + area.height - height
+	for _, line := range lines {
+		total := 0.0
+		for _, entry := range line {
+			total += entry.width
+		}
+
+		x := area.x + (area.width-total)/2
+		for _, entry := range line {
+			e.pdf.SetFillColor(entry.color.R, entry.color.G, entry.color.B)
+			e.pdf.Rect(x, y+(chartLabelHeight-swatch)/2, swatch, swatch, "F")
+			e.pdf.SetXY(x+swatch+1, y)
+			e.pdf.CellFormat(entry.width-swatch-1, chartLabelHeight, entry.label, "", 0, "L", false, 0, "")
+			x += entry.width
+		}
+		y += chartLabelHeight
+	}
+
+	return height
+}
+
+// drawAxisChart draws a bar, stacked bar or line chart with a value axis,
+// horizontal grid lines and category labels.
+func (e *Engine) drawAxisChart(chart *models.Chart, chartType string, data chartData, area chartArea) {
+	low, high := chartValueRange(chartType, data)
+	minValue, maxValue, step := niceChartScale(low, high, chartTickCount)
+
+	tickCount := min(int(math.Round((maxValue-minValue)/step)), maxChartTicks)
+	var ticks []float64
+	for idx := 0; idx <= tickCount; idx++ {
+		ticks = append(ticks, minValue+float64(idx)*step)
+	}
+
+	tickWidth := 0.0
+	for _, tick := range ticks {
+		if width := e.pdf.GetStringWidth(formatValue(tick, chart.Format)); width > tickWidth {
+			tickWidth = width
+		}
+	}
+	tickWidth += 2
+
+	yAxisTitle := e.processTemplate(chart.YAxisTitle)
+	xAxisTitle := e.processTemplate(chart.XAxisTitle)
+	left := area.x + tickWidth
+	if yAxisTitle != "" {
+		left += chartLabelHeight
+	}
+	plot := chartArea{
+		x:      left,
+		y:      area.y + chartLabelHeight/2,
+		width:  area.x + area.width - left - 1,
+		height: area.height - chartLabelHeight/2 - chartLabelHeight,
+	}
+	if xAxisTitle != "" {
+		plot.height -= chartLabelHeight
+	}
+	if plot.width <= 0 || plot.height <= 0 {
+		return
+	}
+
+	valueY := func(value float64) float64 {
+		return plot.y + plot.height - (value-minValue)/(maxValue-minValue)*plot.height
+	}
+
+	// Grid lines and value labels.
+	e.pdf.SetLineWidth(0.1)
+	e.pdf.SetDrawColor(217, 217, 217)
+	for _, tick := range ticks {
+		tickY := valueY(tick)
+		e.pdf.Line(plot.x, tickY, plot.x+plot.width, tickY)
+		e.pdf.SetXY(plot.x-tickWidth, tickY-chartLabelHeight/2)
+		e.pdf.CellFormat(tickWidth-1, chartLabelHeight, formatValue(tick, chart.Format), "", 0, "R", false, 0, "")
+	}
+
+	count := len(data.labels)
+	if count > 0 {
+		band := plot.width / float64(count)
+		switch chartType {
+		case "line":
+			e.drawLineSeries(data, plot, band, valueY)
+		case "stackedbar":
+			e.drawStackedBars(data, plot, band, valueY)
+		default:
+			e.drawGroupedBars(data, plot, band, valueY, minValue)
+		}
+
+		labelY := plot.y + plot.height + 1
+		for idx, label := range data.labels {
+			e.pdf.SetXY(plot.x+float64(idx)*band, labelY)
+			e.pdf.CellFormat(band, chartLabelHeight, label, "", 0, "C", false, 0, "")
+		}
+	}
+
+	// Axes.
+	baseline := plot.y + plot.height
+	if minValue < 0 && maxValue > 0 {
+		baseline = valueY(0)
+	}
+	e.pdf.SetLineWidth(0.2)
+	e.pdf.SetDrawColor(89, 89, 89)
+	e.pdf.Line(plot.x, plot.y, plot.x, plot.y+plot.height)
+	e.pdf.Line(plot.x, baseline, plot.x+plot.width, baseline)
+
+	if xAxisTitle != "" {
+		e.pdf.SetXY(plot.x, plot.y+plot.height+1+chartLabelHeight)
+		e.pdf.CellFormat(plot.width, chartLabelHeight, xAxisTitle, "", 0, "C", false, 0, "")
+	}
+	if yAxisTitle != "" {
+		centerX := area.x + chartLabelHeight*0.7
+		centerY := plot.y + plot.height/2
+		e.pdf.TransformBegin()
+		e.pdf.TransformRotate(90, centerX, centerY)
+		e.pdf.Text(centerX-e.pdf.GetStringWidth(yAxisTitle)/2, centerY, yAxisTitle)
+		e.pdf.TransformEnd()
+	}
+}
+
+// drawGroupedBars draws one bar per series side by side in each category.
+func (e *Engine) drawGroupedBars(data chartData, plot chartArea, band float64, valueY func(float64) float64, minValue float64) {
+	if len(data.series) == 0 {
+		return
+	}
+
+	base := math.Max(minValue, 0)
+	groupWidth := band * 0.7
+	barWidth := groupWidth / float64(len(data.series))
+	for seriesIdx, series := range data.series {
+		e.pdf.SetFillColor(series.color.R, series.color.G, series.color.B)
+		for idx, value := range series.values {
+			top, bottom := valueY(value), valueY(base)
+			if top > bottom {
+				top, bottom = bottom, top
+			}
+			x := plot.x + float64(idx)*band + (band-groupWidth)/2 + float64(seriesIdx)*barWidth
+			e.pdf.Rect(x, top, barWidth, bottom-top, "F")
+		}
+	}
+}
+
+// drawStackedBars draws the series of each category stacked in one bar.
+// Negative values are not stacked.
+func (e *Engine) drawStackedBars(data chartData, plot chartArea, band float64, valueY func(float64) float64) {
+	barWidth := band * 0.6
+	for idx := range data.labels {
+		total := 0.0
+		x := plot.x + float64(idx)*band + (band-barWidth)/2
+		for _, series := range data.series {
+			value := series.values[idx]
+			if value <= 0 {
+				continue
+			}
+			e.pdf.SetFillColor(series.color.R, series.color.G, series.color.B)
+			e.pdf.Rect(x, valueY(total+value), barWidth, valueY(total)-valueY(total+value), "F")
+			total += value
+		}
+	}
+}
+
+// drawLineSeries draws each series as a polyline through the category
+// centers with a marker on every point.
+func (e *Engine) drawLineSeries(data chartData, plot chartArea, band float64, valueY func(float64) float64) {
+	e.pdf.SetLineWidth(0.5)
+	for _, series := range data.series {
+		e.pdf.SetDrawColor(series.color.R, series.color.G, series.color.B)
+		e.pdf.SetFillColor(series.color.R, series.color.G, series.color.B)
+
+		var previousX, previousY float64
+		for idx, value := range series.values {
+			x := plot.x + (float64(idx)+0.5)*band
+			y := valueY(value)
+			if idx > 0 {
+				e.pdf.Line(previousX, previousY, x, y)
+			}
+			e.pdf.Circle(x, y, 0.8, "F")
+			previousX, previousY = x, y
+		}
+	}
+}
+
+// drawPieChart draws the first series as a pie, starting at twelve o'clock
+// and running clockwise. Zero and negative values get no slice.
+func (e *Engine) drawPieChart(data chartData, area chartArea) {
+	if len(data.series) == 0 {
+		return
+	}
+
+	values := data.series[0].values
+	total := 0.0
+	for _, value := range values {
+		if value > 0 {
+			total += value
+		}
+	}
+	radius := math.Min(area.width, area.height)/2 - 1
+	if total <= 0 || radius <= 0 {
+		return
+	}
+
+	centerX := area.x + area.width/2
+	centerY := area.y + area.height/2
+	colors := chartSliceColors(data)
+	angle := -math.Pi / 2
+	for idx, value := range values {
+		if value <= 0 {
+			continue
+		}
+
+		sweep := value / total * 2 * math.Pi
+		steps := int(math.Ceil(sweep / (math.Pi / 36)))
+		points := []gofpdf.PointType{{X: centerX, Y: centerY}}
+		for step := 0; step <= steps; step++ {
+			a := angle + sweep*float64(step)/float64(steps)
+			points = append(points, gofpdf.PointType{X: centerX + radius*math.Cos(a), Y: centerY + radius*math.Sin(a)})
+		}
+
+		e.pdf.SetFillColor(colors[idx].R, colors[idx].G, colors[idx].B)
+		e.pdf.Polygon(points, "F")
+		angle += sweep
+	}
+}
+
+// chartSliceColors returns one color per category for pie slices, cycling
+// through the chart palette.
+func chartSliceColors(data chartData) []models.RGBColor {
+	colors := make([]models.RGBColor, len(data.labels))
+	for idx := range colors {
+		colors[idx] = data.palette[idx%len(data.palette)]
+	}
+	return colors
+}
+
+// chartValueRange returns the lowest and highest value the value axis must
+// show. Bar charts always include zero; stacked bars use category totals.
+func chartValueRange(chartType string, data chartData) (float64, float64) {
+	var low, high float64
+	first := true
+	include := func(value float64) {
+		if first || value < low {
+			low = value
+		}
+		if first || value > high {
+			high = value
+		}
+		first = false
+	}
+
+	if chartType == "stackedbar" {
+		include(0)
+		for idx := range data.labels {
+			total := 0.0
+			for _, series := range data.series {
+				total += math.Max(series.values[idx], 0)
+			}
+			include(total)
+		}
+		return low, high
+	}
+
+	if chartType != "line" {
+		include(0)
+	}
+	for _, series := range data.series {
+		for _, value := range series.values {
+			include(value)
+		}
+	}
+	return low, high
+}
+
+// niceChartScale widens a value range to round axis bounds and returns the
+// bounds with a tick step of 1, 2 or 5 times a power of ten.
+func niceChartScale(low, high float64, ticks int) (float64, float64, float64) {
+	if high <= low {
+		high = low + 1
+	}
+
+	step := niceChartNumber((high - low) / float64(ticks))
+	minValue, maxValue := math.Floor(low/step)*step, math.Ceil(high/step)*step
+	if maxValue <= minValue {
+		// The step is below the spacing of floats this large.
+		maxValue = math.Nextafter(minValue, math.Inf(1))
+	}
+	return minValue, maxValue, step
+}
+
+func niceChartNumber(value float64) float64 {
+	exponent := math.Floor(math.Log10(value))
+	fraction := value / math.Pow(10, exponent)
+
+	var nice float64
+	switch {
+	case fraction <= 1:
+		nice = 1
+	case fraction <= 2:
+		nice = 2
+	case fraction <= 5:
+		nice = 5
+	default:
+		nice = 10
+	}
+	return nice * math.Pow(10, exponent)
+}
@@ -0,0 +1,183 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+func chartTestSales() []interface{} {
+	return []interface{}{
+		map[string]interface{}{"region": "North", "q1": 120.0, "q2": 80.0},
+		map[string]interface{}{"region": "South", "q1": 45.0, "q2": 60.0},
+		map[string]interface{}{"region": "West", "q1": 90.0, "q2": "n/a"},
+	}
+}
+
+func TestNiceChartScale(t *testing.T) {
+	tests := []struct {
+		low, high        float64
+		wantMin, wantMax float64
+		wantStep         float64
+	}{
+		{0, 87, 0, 100, 20},
+		{-12, 40, -20, 40, 20},
+		{0.2, 0.9, 0.2, 1, 0.2},
+		{5, 5, 5, 6, 0.2},
+	}
+
+	for _, tt := range tests {
+		minValue, maxValue, step := niceChartScale(tt.low, tt.high, chartTickCount)
+		if !nearlyEqual(minValue, tt.wantMin) || !nearlyEqual(maxValue, tt.wantMax) || !nearlyEqual(step, tt.wantStep) {
+			t.Fatalf("niceChartScale(%v, %v) = %v, %v, %v; want %v, %v, %v",
+				tt.low, tt.high, minValue, maxValue, step, tt.wantMin, tt.wantMax, tt.wantStep)
+		}
+	}
+}
+
+func TestChartValueRange(t *testing.T) {
+	data := chartData{
+		labels: []string{"a", "b"},
+		series: []chartSeriesData{
+			{values: []float64{10, 30}},
+			{values: []float64{25, -5}},
+		},
+	}
+
+	if low, high := chartValueRange("stackedbar", data); low != 0 || high != 35 {
+		t.Fatalf("expected stacked range 0..35, got %v..%v", low, high)
+	}
+	if low, high := chartValueRange("bar", data); low != -5 || high != 30 {
+		t.Fatalf("expected bar range -5..30, got %v..%v", low, high)
+	}
+	if low, high := chartValueRange("line", chartData{series: []chartSeriesData{{values: []float64{12, 18}}}}); low != 12 || high != 18 {
+		t.Fatalf("expected line range to skip zero, got %v..%v", low, high)
+	}
+}
+
+func TestRenderChartDrawsBarChartWithinHeight(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Sales": chartTestSales()})
+	engine.styles["q1"] = &models.Style{Name: "q1", FillColor: &models.RGBColor{R: 255, G: 128}}
+
+	startY := engine.pdf.GetY()
+	engine.renderChart(&models.Chart{
+		Type:       "bar",
+		DataSource: "{{.Sales}}",
+		LabelField: "region",
+		Series: []models.ChartSeries{
+			{Field: "q1", Label: "First quarter", Style: "q1"},
+			{Field: "q2", Label: "Second quarter"},
+		},
+		Height:     70,
+		Title:      "Sales by region",
+		XAxisTitle: "Region",
+		YAxisTitle: "Revenue",
+	})
+
+	if got, want := engine.pdf.GetY(), startY+70; got != want {
+		t.Fatalf("expected chart to advance the flow by its height (Y %.2f), got %.2f", want, got)
+	}
+
+	output := renderedPDF(t, engine)
+	for _, want := range []string{
+		"(Sales by region)", "(North)", "(South)", "(West)",
+		"(First quarter)", "(Second quarter)", "(Revenue)", "(Region)",
+		"1.000 0.502 0.000 rg",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %s in chart output", want)
+		}
+	}
+}
+
+func TestRenderChartDrawsPieLegendPerSlice(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Sales": chartTestSales()})
+
+	engine.renderChart(&models.Chart{
+		Type:       "pie",
+		DataSource: "{{.Sales}}",
+		LabelField: "region",
+		ValueField: "q1",
+	})
+
+	output := renderedPDF(t, engine)
+	for _, want := range []string{"(North)", "(South)", "(West)"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected pie legend entry %s", want)
+		}
+	}
+}
+
+func TestRenderRowPlacesChartBesideText(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Sales": chartTestSales()})
+
+	startY := engine.pdf.GetY()
+	err := engine.renderRow(&models.Row{Elements: []models.SectionElement{
+		{Type: "text", Text: &models.Text{Style: "body", Width: 50, Content: "Quarterly trend"}},
+		{Type: "chart", Chart: &models.Chart{
+			Type:       "line",
+			DataSource: "{{.Sales}}",
+			LabelField: "region",
+			ValueField: "q1",
+			Width:      80,
+			Height:     40,
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("renderRow returned error: %v", err)
+	}
+
+	if got, want := engine.pdf.GetY(), startY+40; got != want {
+		t.Fatalf("expected row to grow to the chart height (Y %.2f), got %.2f", want, got)
+	}
+}
+
+func TestRenderChartHandlesPreciseAndNonFiniteValues(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Points": []interface{}{
+		map[string]interface{}{"label": "a", "value": 1e16},
+		map[string]interface{}{"label": "b", "value": 1e16 + 2},
+		map[string]interface{}{"label": "c", "value": "NaN"},
+		map[string]interface{}{"label": "d", "value": "Inf"},
+	}})
+	chart := &models.Chart{Type: "line", DataSource: "{{.Points}}", LabelField: "label", ValueField: "value"}
+
+	data := engine.resolveChartData(chart)
+	if values := data.series[0].values; values[2] != 0 || values[3] != 0 {
+		t.Fatalf("expected non-finite values to count as zero, got %v", values)
+	}
+
+	minValue, maxValue, _ := niceChartScale(1e16, 1e16, chartTickCount)
+	if maxValue <= minValue {
+		t.Fatalf("expected a non-empty scale, got %v..%v", minValue, maxValue)
+	}
+
+	// A step below the float spacing of the values must not stall the axis.
+	chart.DataQuery = models.DataQuery{Limit: 2}
+	engine.renderChart(chart)
+	if engine.pdf.Err() {
+		t.Fatalf("chart rendering failed: %v", engine.pdf.Error())
+	}
+}
+
+func TestRenderRowKeepsChartOnRowPage(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Sales": chartTestSales()})
+	engine.pdf.SetY(engine.pageBreakTrigger() - 20)
+
+	err := engine.renderRow(&models.Row{Elements: []models.SectionElement{
+		{Type: "text", Text: &models.Text{Style: "body", Width: 50, Content: "Quarterly trend"}},
+		{Type: "chart", Chart: &models.Chart{Type: "line", DataSource: "{{.Sales}}", LabelField: "region", ValueField: "q1", Width: 80, Height: 40}},
+	}})
+	if err != nil {
+		t.Fatalf("renderRow returned error: %v", err)
+	}
+
+	if engine.pdf.PageNo() != 1 {
+		t.Fatalf("expected the chart to stay beside the text on page 1, got page %d", engine.pdf.PageNo())
+	}
+}
+
+func nearlyEqual(a, b float64) bool {
+	diff := a - b
+	return diff < 1e-9 && diff > -1e-9
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+type testCustomer struct {
+	Name string `json:"name"`
+}
+
+type testLineItem struct {
+	SKU      string       `json:"sku"`
+	Qty      int          `json:"qty"`
+	Price    float64      `json:"price"`
+	Customer testCustomer `json:"customer"`
+}
+
+type testInvoice struct {
+	Number string
+	Lines  []testLineItem
+	Notes  []string
+}
+
+type testStatus string
+
+type testLevel int
+
+func TestFormatValueFormatsBasicValuesOnly(t *testing.T) {
+	cases := []struct {
+		value  interface{}
+		format string
+		want   string
+	}{
+		{value: 3, format: "currency", want: "$3"},
+		{value: -4.25, format: "currency", want: "-$4.25"},
+		{value: -4, format: "currency", want: "-$4"},
+		{value: -4.256, want: "-4.26"},
+		{value: -0.004, format: "currency", want: "$0.00"},
+		{value: 1, format: "percent", want: "100%"},
+		{value: testLevel(2), format: "currency", want: "$2"},
+		{value: testStatus("open"), want: "open"},
+		{value: true, want: "true"},
+		{value: time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC), want: "2026-03-07 00:00:00 +0000 UTC"},
+		{value: nil, want: ""},
+		{value: map[string]interface{}{"a": 1}, want: ""},
+		{value: []int{1, 2}, want: ""},
+		{value: testCustomer{Name: "Acme"}, want: ""},
+	}
+
+	for _, tc := range cases {
+		if got := formatValue(tc.value, tc.format); got != tc.want {
+			t.Fatalf("expected formatValue(%#v, %q) to be %q, got %q", tc.value, tc.format, tc.want, got)
+		}
+	}
+}
+
+func TestResolveDataPathSupportsJSONTagsAndNestedFields(t *testing.T) {
+	item := &testLineItem{SKU: "A-1", Customer: testCustomer{Name: "Acme"}}
+
+	if got, ok := resolveDataPath(item, "sku"); !ok || got != "A-1" {
+		t.Fatalf("expected json tag lookup to resolve sku, got %v (ok=%v)", got, ok)
+	}
+	if got, ok := resolveDataPath(item, "SKU"); !ok || got != "A-1" {
+		t.Fatalf("expected Go field name lookup to resolve SKU, got %v (ok=%v)", got, ok)
+	}
+	if got, ok := resolveDataPath(item, "customer.name"); !ok || got != "Acme" {
+		t.Fatalf("expected dotted lookup to resolve customer.name, got %v (ok=%v)", got, ok)
+	}
+	if _, ok := resolveDataPath(item, "customer.missing"); ok {
+		t.Fatalf("expected missing nested field to be unresolved")
+	}
+}
+
+func TestRenderTableResolvesStructRowsFromNestedPath(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{
+		"Invoice": testInvoice{
+			Number: "INV-9",
+			Lines: []testLineItem{
+				{SKU: "SKU-RED", Qty: 3, Price: 2.5, Customer: testCustomer{Name: "Northwind"}},
+				{SKU: "SKU-BLUE", Qty: 1, Price: 10, Customer: testCustomer{Name: "Contoso"}},
+			},
+		},
+	})
+
+	engine.renderTable(&models.Table{
+		DataSource: "{{.Invoice.Lines}}",
+		Columns: models.Columns{Columns: []models.Column{
+			{Header: "SKU", Field: "sku", Width: "40"},
+			{Header: "Qty", Field: "Qty", Width: "20"},
+			{Header: "Customer", Field: "customer.name", Width: "50"},
+		}},
+	})
+
+	output := renderedPDF(t, engine)
+	for _, want := range []string{"(SKU-RED)", "(SKU-BLUE)", "(3)", "(Northwind)", "(Contoso)"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %s in struct-backed table output", want)
+		}
+	}
+}
+
+func TestRenderListResolvesNestedDataPath(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{
+		"Invoice": &testInvoice{Notes: []string{"first note", "second note"}},
+	})
+
+	engine.renderList(&models.List{Items: "{{.Invoice.Notes}}"})
+
+	output := renderedPDF(t, engine)
+	if !strings.Contains(output, "first note") || !strings.Contains(output, "second note") {
+		t.Fatalf("expected nested list items to be rendered")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"maps"
 	"math"
 	"os"
 	"reflect"
@@ -28,14 +29,79 @@ type Engine struct {
 	flowOffsetRight float64
 	positionOffsetY float64
 	embeddedFonts   []models.EmbeddedFont
+
+	// anchors maps anchor names to gofpdf link IDs. Links can refer to an
+	// anchor before it is defined; anchorPages records the page of each
+	// defined one. pageRef reads knownAnchorPages, from the previous pass,
+	// and records the names it is asked for in pageRefs.
+	anchors          map[string]int
+	anchorPages      map[string]int
+	knownAnchorPages map[string]int
+	pageRefs         map[string]bool
+
+	// tocEntries are the table of contents entries recorded by the previous
+	// layout pass, which <toc> draws. tocCollected gathers this pass's.
+	tocEntries   []tocEntry
+	tocCollected []tocEntry
+	tocLinks     map[int]int
+	tocRendered  bool
+
+	// bookmarkLevel is the level of the last outline entry, or -1 before the
+	// first. Marked headings are nested from headingBookmarkLevel.
+	bookmarkLevel        int
+	headingBookmarkLevel int
+
+	// footnoteCount numbers footnotes through the document. pageFootnotes
+	// wait for the bottom of the current page, where footnoteReserved is
+	// kept free above the original bottom margin, footnoteMargin.
+	// undefinedFootnotes records markers referring to no declared note.
+	footnoteCount      int
+	pageFootnotes      []footnote
+	footnoteReserved   float64
+	footnoteMargin     float64
+	undefinedFootnotes map[string]bool
+
+	// fonts are the fonts loaded into the PDF, which measureLayout loads
+	// into scratches, the documents for dry runs, one for each level of
+	// nesting. measuring is set during one, with measureDepth the number of
+	// dry runs in progress, and paginating when its pages break like the
+	// report's, which scratchPageEnds then records.
+	fonts           []models.EmbeddedFont
+	scratches       []*gofpdf.Fpdf
+	measuring       bool
+	measureDepth    int
+	paginating      bool
+	scratchPageEnds []float64
+
+	// lineBreakTriggers are the planned page ends of the paragraph drawn by
+	// multiCellWithBreaks while lineBreaksActive, and lineBreakMargin the
+	// bottom margin to restore after them.
+	lineBreakTriggers []float64
+	lineBreakMargin   float64
+	lineBreaksActive  bool
+
+	// boxFragments are the backgrounds of boxes continuing onto later pages,
+	// drawn when those pages start so content covers them.
+	boxFragments []boxFragment
+
+	// inRow is set while row and rowgrid children render. Charts then leave
+	// pagination to the container rather than start a page of their own.
+	inRow bool
+
+	// fontFamily and fontStyle track the current font, which gofpdf does
+	// not expose.
+	fontFamily string
+	fontStyle  string
 }
 
 // New creates a new Engine instance.
 func New() *Engine {
-	return &Engine{
+	e := &Engine{
 		styles:  make(map[string]*models.Style),
 		funcMap: defaultFuncMap(),
 	}
+	e.funcMap["pageRef"] = e.pageRef
+	return e
 }
 
 // SetReport sets the parsed report template.
@@ -70,16 +136,7 @@ func (e *Engine) Generate(w io.Writer) error {
 		return fmt.Errorf("no report template loaded")
 	}
 
-	// Initialize PDF
-	e.initPDF()
-
-	// Set up header/footer
-	e.setupHeaderFooter()
-
-	// Add first page
-	e.pdf.AddPage()
-
-	if err := e.renderSections(); err != nil {
+	if err := e.render(); err != nil {
 		return err
 	}
 
@@ -98,11 +155,7 @@ func (e *Engine) GenerateToFile(filepath string) error {
 		return fmt.Errorf("no report template loaded")
 	}
 
-	e.initPDF()
-	e.setupHeaderFooter()
-	e.pdf.AddPage()
-
-	if err := e.renderSections(); err != nil {
+	if err := e.render(); err != nil {
 		return err
 	}
 
@@ -113,6 +166,46 @@ func (e *Engine) GenerateToFile(filepath string) error {
 	return e.pdf.OutputFileAndClose(filepath)
 }
 
+// maxLayoutPasses bounds how often a report is laid out while waiting for
+// table of contents and page reference numbers to settle.
+const maxLayoutPasses = 4
+
+// render lays out the report into a new PDF. A report with a table of
+// contents or page references is laid out again with the page numbers of
+// the previous pass until they stop changing, as the numbers themselves can
+// move later pages.
+func (e *Engine) render() error {
+	e.tocEntries = nil
+	e.knownAnchorPages = nil
+	for pass := 1; ; pass++ {
+		e.initPDF()
+		e.setupHeaderFooter()
+		e.pdf.AddPage()
+
+		if err := e.renderSections(); err != nil {
+			return err
+		}
+
+		if e.pdf.Err() || pass == maxLayoutPasses || e.layoutSettled() {
+			return nil
+		}
+		e.tocEntries = e.tocCollected
+		e.knownAnchorPages = e.anchorPages
+	}
+}
+
+// layoutSettled reports whether the page numbers used by the last pass
+// match the pages it produced.
+func (e *Engine) layoutSettled() bool {
+	if e.tocRendered && !tocEntriesEqual(e.tocEntries, e.tocCollected) {
+		return false
+	}
+	if len(e.pageRefs) > 0 && !maps.Equal(e.knownAnchorPages, e.anchorPages) {
+		return false
+	}
+	return true
+}
+
 func (e *Engine) renderSections() error {
 	for _, section := range e.report.Sections.Sections {
 		if err := e.renderSection(&section); err != nil {
@@ -120,7 +213,10 @@ func (e *Engine) renderSections() error {
 		}
 	}
 
-	return nil
+	if err := e.checkFootnotes(); err != nil {
+		return err
+	}
+	return e.checkAnchors()
 }
 
 // initPDF initializes the PDF document.
@@ -138,20 +234,39 @@ func (e *Engine) initPDF() {
 		e.pdf.SetAutoPageBreak(true, doc.Margins.Bottom)
 	}
 
+	e.anchors = make(map[string]int)
+	e.anchorPages = make(map[string]int)
+	e.pageRefs = make(map[string]bool)
+	e.tocCollected = nil
+	e.tocLinks = make(map[int]int)
+	e.tocRendered = false
+	e.bookmarkLevel = -1
+	e.headingBookmarkLevel = 0
+	e.footnoteCount = 0
+	e.pageFootnotes = nil
+	e.footnoteReserved = 0
+	e.undefinedFootnotes = make(map[string]bool)
+	e.lineBreaksActive = false
+	e.boxFragments = nil
+	e.pdf.SetAcceptPageBreakFunc(e.acceptPageBreak)
+
 	// Register a placeholder that gofpdf replaces with the total page count
 	// at output time. Header/footer templates can use {{.TotalPages}}.
 	e.pdf.AliasNbPages("{nb}")
 
 	// Ensure unstyled text elements can render even when no explicit style has
 	// selected a font yet. Styled content will override this as needed.
-	e.pdf.SetFont("Arial", "", 12)
+	e.setFont("Arial", "", 12)
 
+	e.fonts = nil
+	e.scratches = nil
 	loadedFonts := make(map[string]bool)
 	for _, font := range e.embeddedFonts {
 		if len(font.Data) == 0 {
 			continue
 		}
 		e.pdf.AddUTF8FontFromBytes(font.Family, font.Style, font.Data)
+		e.fonts = append(e.fonts, font)
 		loadedFonts[fontKey(font.Family, font.Style)] = true
 	}
 
@@ -169,6 +284,7 @@ func (e *Engine) initPDF() {
 				continue
 			}
 			e.pdf.AddUTF8FontFromBytes(font.Family, font.Style, fontBytes)
+			e.fonts = append(e.fonts, models.EmbeddedFont{Family: font.Family, Style: font.Style, Data: fontBytes})
 		}
 	}
 }
@@ -192,15 +308,17 @@ func (e *Engine) buildStyleMap() {
 func (e *Engine) setupHeaderFooter() {
 	e.pdf.SetHeaderFuncMode(func() {
 		if e.report.Header != nil && e.report.Header.Enabled {
-			e.renderHeaderFooterElements(0, e.report.Header.Texts, e.report.Header.Images, e.report.Header.Lines)
+			e.renderHeaderFooterElements(0, e.report.Header.Texts, e.report.Header.Images, e.report.Header.Lines, e.report.Header.QRCodes)
 		}
+		e.drawBoxFragments()
 	}, true)
 
 	e.pdf.SetFooterFunc(func() {
+		e.drawFootnotes()
 		if e.report.Footer != nil && e.report.Footer.Enabled {
 			e.pdf.SetY(-e.report.Footer.Height)
 			_, pageHeight := e.pdf.GetPageSize()
-			e.renderHeaderFooterElements(pageHeight-e.report.Footer.Height, e.report.Footer.Texts, e.report.Footer.Images, e.report.Footer.Lines)
+			e.renderHeaderFooterElements(pageHeight-e.report.Footer.Height, e.report.Footer.Texts, e.report.Footer.Images, e.report.Footer.Lines, e.report.Footer.QRCodes)
 		}
 	})
 }
@@ -208,7 +326,7 @@ func (e *Engine) setupHeaderFooter() {
 // renderHeaderFooterElements renders header/footer elements.
 // It temporarily injects PageNumber and TotalPages into the data map
 // so templates can use {{.PageNumber}} and {{.TotalPages}}.
-func (e *Engine) renderHeaderFooterElements(positionOffsetY float64, texts []models.Text, images []models.Image, lines []models.Line) {
+func (e *Engine) renderHeaderFooterElements(positionOffsetY float64, texts []models.Text, images []models.Image, lines []models.Line, qrCodes []models.QRCode) {
 	original := e.data
 	originalOffsetY := e.positionOffsetY
 	scoped := cloneDataMap(original)
@@ -216,9 +334,15 @@ func (e *Engine) renderHeaderFooterElements(positionOffsetY float64, texts []mod
 	scoped["TotalPages"] = "{nb}"
 	e.data = scoped
 	e.positionOffsetY = positionOffsetY
+
+	// Header and footer content is positioned explicitly and must never
+	// trigger an automatic page break of its own.
+	autoPageBreak, bottomMargin := e.pdf.GetAutoPageBreak()
+	e.pdf.SetAutoPageBreak(false, bottomMargin)
 	defer func() {
 		e.data = original
 		e.positionOffsetY = originalOffsetY
+		e.pdf.SetAutoPageBreak(autoPageBreak, bottomMargin)
 	}()
 
 	for _, text := range texts {
@@ -230,6 +354,13 @@ func (e *Engine) renderHeaderFooterElements(positionOffsetY float64, texts []mod
 	for _, line := range lines {
 		e.renderLine(&line)
 	}
+	for _, qr := range qrCodes {
+		// Header and footer functions cannot return errors, so record it on
+		// the document for Render to report.
+		if err := e.renderQRCode(&qr); err != nil {
+			e.pdf.SetError(err)
+		}
+	}
 }
 
 func (e *Engine) resolvePositionedY(y, currentY, pageHeight float64) float64 {
@@ -245,6 +376,7 @@ func (e *Engine) resolvePositionedY(y, currentY, pageHeight float64) float64 {
 // renderSection renders a section and its elements.
 func (e *Engine) renderSection(section *models.Section) error {
 	rendered := false
+	item := 0
 	renderCurrentContext := func() error {
 		if !e.shouldRenderCondition(section.Condition) {
 			return nil
@@ -253,11 +385,26 @@ func (e *Engine) renderSection(section *models.Section) error {
 		if !rendered && section.PageBreakBefore {
 			e.pdf.AddPage()
 		}
+		renderElements := func() error {
+			return e.withFlowOffset(section.PaddingLeft, func() error {
+				return e.renderSectionElements(section)
+			})
+		}
+		if section.KeepTogether {
+			if err := e.keepTogether(renderElements); err != nil {
+				return err
+			}
+		}
+		if section.Anchor != "" {
+			e.defineAnchor(section.Anchor)
+		}
+		if title, ok := e.tocTitle(section.TocEntry, sectionTitle(section)); ok {
+			e.addTocEntry(title, section.TocLevel, e.pdf.PageNo(), e.pdf.GetY())
+		}
+		e.bookmarkSection(section, !rendered, item)
 
 		rendered = true
-		return e.withFlowOffset(section.PaddingLeft, func() error {
-			return e.renderSectionElements(section)
-		})
+		return renderElements()
 	}
 
 	if section.Loop != "" {
@@ -271,8 +418,9 @@ func (e *Engine) renderSection(section *models.Section) error {
 			return nil
 		}
 
-		for _, item := range items {
-			if err := e.withScopedData(loopVariable, item, renderCurrentContext); err != nil {
+		for idx, value := range e.applyDataQuery(items, section.DataQuery, loopVariable) {
+			item = idx + 1
+			if err := e.withScopedData(loopVariable, value, renderCurrentContext); err != nil {
 				return err
 			}
 		}
@@ -292,11 +440,25 @@ func (e *Engine) renderSectionElements(section *models.Section) error {
 }
 
 func (e *Engine) renderElements(elements []models.SectionElement) error {
-	for _, elem := range elements {
+	for idx, elem := range elements {
 		if !e.shouldRenderCondition(e.getElementCondition(elem)) {
 			continue
 		}
 
+		if elem.Type == "text" && elem.Text != nil && elem.Text.KeepWithNext {
+			if err := e.keepWithNext(elements[idx:]); err != nil {
+				return err
+			}
+		}
+		base := elementBase(elem)
+		if base != nil && base.KeepTogether {
+			if err := e.keepTogether(func() error { return e.renderElement(elem) }); err != nil {
+				return err
+			}
+		}
+		if base != nil && base.Anchor != "" && !definesOwnAnchor(elem.Type) {
+			e.defineAnchor(base.Anchor)
+		}
 		if err := e.renderElement(elem); err != nil {
 			return err
 		}
@@ -313,6 +475,24 @@ func (e *Engine) renderElement(elem models.SectionElement) error {
 		e.renderImage(elem.Image)
 	case "table":
 		e.renderTable(elem.Table)
+	case "pivot":
+		e.renderPivot(elem.Pivot)
+	case "chart":
+		e.renderChart(elem.Chart)
+	case "sparkline":
+		e.renderSparkline(elem.Sparkline)
+	case "progressBar":
+		e.renderProgressBar(elem.Progress)
+	case "qrcode":
+		if err := e.renderQRCode(elem.QRCode); err != nil {
+			return err
+		}
+	case "barcode":
+		if err := e.renderBarcode(elem.Barcode); err != nil {
+			return err
+		}
+	case "toc":
+		e.renderToc(elem.Toc)
 	case "list":
 		e.renderList(elem.List)
 	case "keyValueList":
@@ -329,6 +509,10 @@ func (e *Engine) renderElement(elem models.SectionElement) error {
 		if err := e.renderRowGrid(elem.RowGrid); err != nil {
 			return err
 		}
+	case "box":
+		if err := e.renderBox(elem.Box); err != nil {
+			return err
+		}
 	case "spacer":
 		e.renderSpacer(elem.Spacer)
 	case "pageBreak":
@@ -339,54 +523,142 @@ func (e *Engine) renderElement(elem models.SectionElement) error {
 }
 
 func (e *Engine) getElementCondition(elem models.SectionElement) string {
+	if elem.Type == "pageBreak" {
+		if elem.PageBreak != nil {
+			return elem.PageBreak.Condition
+		}
+		return ""
+	}
+
+	if base := elementBase(elem); base != nil {
+		return base.Condition
+	}
+	return ""
+}
+
+// elementBase returns the attributes shared by section elements, or nil for
+// page breaks and missing elements.
+func elementBase(elem models.SectionElement) *models.BaseElement {
 	switch elem.Type {
 	case "text":
 		if elem.Text != nil {
-			return elem.Text.Condition
+			return &elem.Text.BaseElement
 		}
 	case "image":
 		if elem.Image != nil {
-			return elem.Image.Condition
+			return &elem.Image.BaseElement
 		}
 	case "table":
 		if elem.Table != nil {
-			return elem.Table.Condition
+			return &elem.Table.BaseElement
+		}
+	case "pivot":
+		if elem.Pivot != nil {
+			return &elem.Pivot.BaseElement
+		}
+	case "chart":
+		if elem.Chart != nil {
+			return &elem.Chart.BaseElement
+		}
+	case "sparkline":
+		if elem.Sparkline != nil {
+			return &elem.Sparkline.BaseElement
+		}
+	case "progressBar":
+		if elem.Progress != nil {
+			return &elem.Progress.BaseElement
+		}
+	case "qrcode":
+		if elem.QRCode != nil {
+			return &elem.QRCode.BaseElement
+		}
+	case "barcode":
+		if elem.Barcode != nil {
+			return &elem.Barcode.BaseElement
+		}
+	case "toc":
+		if elem.Toc != nil {
+			return &elem.Toc.BaseElement
 		}
 	case "list":
 		if elem.List != nil {
-			return elem.List.Condition
+			return &elem.List.BaseElement
 		}
 	case "keyValueList":
 		if elem.KVList != nil {
-			return elem.KVList.Condition
+			return &elem.KVList.BaseElement
 		}
 	case "line":
 		if elem.Line != nil {
-			return elem.Line.Condition
+			return &elem.Line.BaseElement
 		}
 	case "rectangle":
 		if elem.Rectangle != nil {
-			return elem.Rectangle.Condition
+			return &elem.Rectangle.BaseElement
 		}
 	case "row":
 		if elem.Row != nil {
-			return elem.Row.Condition
+			return &elem.Row.BaseElement
 		}
 	case "rowgrid":
 		if elem.RowGrid != nil {
-			return elem.RowGrid.Condition
+			return &elem.RowGrid.BaseElement
+		}
+	case "box":
+		if elem.Box != nil {
+			return &elem.Box.BaseElement
 		}
 	case "spacer":
 		if elem.Spacer != nil {
-			return elem.Spacer.Condition
-		}
-	case "pageBreak":
-		if elem.PageBreak != nil {
-			return elem.PageBreak.Condition
+			return &elem.Spacer.BaseElement
 		}
 	}
 
-	return ""
+	return nil
+}
+
+// definesOwnAnchor reports whether elements of a type may start a new page
+// themselves, so they define their anchor once they know their first page.
+func definesOwnAnchor(elemType string) bool {
+	switch elemType {
+	case "text", "table", "pivot", "chart", "sparkline", "progressBar", "box":
+		return true
+	}
+	return false
+}
+
+// copyElement returns elem with its element copied, so its attributes can be
+// changed without changing the template.
+func copyElement(elem models.SectionElement) models.SectionElement {
+	elem.Text = copyOf(elem.Text)
+	elem.Image = copyOf(elem.Image)
+	elem.Table = copyOf(elem.Table)
+	elem.Pivot = copyOf(elem.Pivot)
+	elem.Chart = copyOf(elem.Chart)
+	elem.Sparkline = copyOf(elem.Sparkline)
+	elem.Progress = copyOf(elem.Progress)
+	elem.QRCode = copyOf(elem.QRCode)
+	elem.Barcode = copyOf(elem.Barcode)
+	elem.Toc = copyOf(elem.Toc)
+	elem.List = copyOf(elem.List)
+	elem.KVList = copyOf(elem.KVList)
+	elem.Line = copyOf(elem.Line)
+	elem.Rectangle = copyOf(elem.Rectangle)
+	elem.Row = copyOf(elem.Row)
+	elem.RowGrid = copyOf(elem.RowGrid)
+	elem.Box = copyOf(elem.Box)
+	elem.Spacer = copyOf(elem.Spacer)
+	elem.PageBreak = copyOf(elem.PageBreak)
+	return elem
+}
+
+// copyOf returns a shallow copy of *value, or nil.
+func copyOf[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
 }
 
 func (e *Engine) shouldRenderCondition(condition string) bool {
@@ -415,6 +687,21 @@ func (e *Engine) withScopedData(key string, value interface{}, fn func() error)
 	return fn()
 }
 
+// withScopedValues runs fn with several additional keys in the data scope.
+func (e *Engine) withScopedValues(values map[string]interface{}, fn func() error) error {
+	original := e.data
+	scoped := cloneDataMap(original)
+	for key, value := range values {
+		scoped[key] = value
+	}
+	e.data = scoped
+	defer func() {
+		e.data = original
+	}()
+
+	return fn()
+}
+
 func (e *Engine) withFlowOffset(offset float64, fn func() error) error {
 	return e.withFlowBounds(offset, 0, fn)
 }
@@ -432,6 +719,17 @@ func (e *Engine) withFlowBounds(leftOffset, rightOffset float64, fn func() error
 	return fn()
 }
 
+// withinRow runs fn with inRow set.
+func (e *Engine) withinRow(fn func() error) error {
+	original := e.inRow
+	e.inRow = true
+	defer func() {
+		e.inRow = original
+	}()
+
+	return fn()
+}
+
 func (e *Engine) flowLeftMargin() float64 {
 	marginLeft, _, _, _ := e.pdf.GetMargins()
 	return marginLeft + e.flowOffsetLeft
@@ -452,6 +750,34 @@ func (e *Engine) flowAvailableWidthFrom(x float64) float64 {
 	return pageWidth - x - e.flowRightMargin()
 }
 
+// pageBreakTrigger returns the Y position beyond which content no longer fits
+// on the current page.
+func (e *Engine) pageBreakTrigger() float64 {
+	_, pageHeight := e.pdf.GetPageSize()
+	_, bottomMargin := e.pdf.GetAutoPageBreak()
+	return pageHeight - bottomMargin
+}
+
+// fitsOnPage reports whether a block of the given height fits between the
+// current Y position and the page break trigger.
+func (e *Engine) fitsOnPage(height float64) bool {
+	return e.pdf.GetY()+height <= e.pageBreakTrigger()
+}
+
+func boolValue(value *bool, fallback bool) bool {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
+func intValue(value *int, fallback int) int {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
 func cloneDataMap(source map[string]interface{}) map[string]interface{} {
 	if source == nil {
 		return map[string]interface{}{}
@@ -466,12 +792,7 @@ func cloneDataMap(source map[string]interface{}) map[string]interface{} {
 }
 
 func (e *Engine) resolveLoopItems(loop string) ([]interface{}, bool) {
-	value, ok := e.resolveTemplateValue(loop)
-	if !ok {
-		return nil, false
-	}
-
-	items := toInterfaceSlice(value)
+	items := e.resolveDataItems(loop)
 	if len(items) == 0 {
 		return nil, false
 	}
@@ -479,6 +800,17 @@ func (e *Engine) resolveLoopItems(loop string) ([]interface{}, bool) {
 	return items, true
 }
 
+// resolveDataItems resolves a template path such as "{{.Invoice.Lines}}" to
+// the slice it points at. Missing paths and non-slice values yield nil.
+func (e *Engine) resolveDataItems(source string) []interface{} {
+	value, ok := e.resolveTemplateValue(source)
+	if !ok {
+		return nil
+	}
+
+	return toInterfaceSlice(value)
+}
+
 func (e *Engine) resolveTemplateValue(tmpl string) (interface{}, bool) {
 	path := extractDataPath(tmpl)
 	if len(path) == 0 {
@@ -497,6 +829,25 @@ func (e *Engine) resolveTemplateValue(tmpl string) (interface{}, bool) {
 	return current, true
 }
 
+// resolveDataPath resolves a dotted field path such as "customer.name"
+// against a single data item.
+func resolveDataPath(current interface{}, path string) (interface{}, bool) {
+	for _, part := range strings.Split(path, ".") {
+		part = trimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var ok bool
+		current, ok = resolveFieldValue(current, part)
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
 func extractDataPath(tmpl string) []string {
 	key := trimSpace(tmpl)
 	if strings.HasPrefix(key, "{{") && strings.HasSuffix(key, "}}") {
@@ -554,6 +905,9 @@ func resolveFieldValue(current interface{}, field string) (interface{}, bool) {
 		return result.Interface(), true
 	case reflect.Struct:
 		result := value.FieldByName(field)
+		if !result.IsValid() {
+			result = structFieldByJSONName(value, field)
+		}
 		if !result.IsValid() || !result.CanInterface() {
 			return nil, false
 		}
@@ -563,6 +917,23 @@ func resolveFieldValue(current interface{}, field string) (interface{}, bool) {
 	}
 }
 
+// structFieldByJSONName finds a struct field by the name in its json tag so
+// templates can use the same keys for structs as for decoded JSON data.
+func structFieldByJSONName(value reflect.Value, name string) reflect.Value {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		tag := valueType.Field(i).Tag.Get("json")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if tagName, _, _ := strings.Cut(tag, ","); tagName == name {
+			return value.Field(i)
+		}
+	}
+
+	return reflect.Value{}
+}
+
 func toInterfaceSlice(value interface{}) []interface{} {
 	if value == nil {
 		return nil
@@ -596,23 +967,41 @@ func toInterfaceSlice(value interface{}) []interface{} {
 	return items
 }
 
-// processTemplate processes a template string with data.
+// processTemplate processes a template string with data. A template that
+// fails is returned as written.
 func (e *Engine) processTemplate(tmplStr string) string {
-	if !strings.Contains(tmplStr, "{{") {
+	text, err := e.executeTemplate(tmplStr)
+	if err != nil {
 		return tmplStr
 	}
+	return text
+}
+
+// executeTemplate processes a template string with data, reporting a
+// template that fails to parse or execute.
+func (e *Engine) executeTemplate(tmplStr string) (string, error) {
+	if !strings.Contains(tmplStr, "{{") {
+		return tmplStr, nil
+	}
 
 	tmpl, err := template.New("").Funcs(e.funcMap).Parse(tmplStr)
 	if err != nil {
-		return tmplStr
+		return "", err
 	}
 
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, e.data); err != nil {
-		return tmplStr
+		return "", err
 	}
 
-	return buf.String()
+	return buf.String(), nil
+}
+
+// setFont selects a font and records its family and style.
+func (e *Engine) setFont(family, style string, size float64) {
+	e.pdf.SetFont(family, style, size)
+	e.fontFamily = family
+	e.fontStyle = style
 }
 
 // applyStyle applies a named style to the PDF.
@@ -624,7 +1013,7 @@ func (e *Engine) applyStyle(styleName string) {
 
 	fontStyle := style.FontStyle
 	if style.FontFamily != "" {
-		e.pdf.SetFont(style.FontFamily, fontStyle, style.FontSize)
+		e.setFont(style.FontFamily, fontStyle, style.FontSize)
 	}
 
 	if style.TextColor != nil {
@@ -638,6 +1027,12 @@ func (e *Engine) applyStyle(styleName string) {
 	}
 }
 
+// styleHasFill reports whether the named style defines a fill color.
+func (e *Engine) styleHasFill(styleName string) bool {
+	style, ok := e.styles[styleName]
+	return ok && style.FillColor != nil
+}
+
 // defaultFuncMap returns the default template function map.
 func defaultFuncMap() template.FuncMap {
 	return template.FuncMap{
@@ -669,14 +1064,50 @@ func defaultValue(def, val interface{}) interface{} {
 	return val
 }
 
-func add(a, b float64) float64 { return a + b }
-func sub(a, b float64) float64 { return a - b }
-func mul(a, b float64) float64 { return a * b }
-func div(a, b float64) float64 {
-	if b == 0 {
-		return 0
+// The arithmetic helpers accept any numeric value, or a numeric string, so
+// templates can combine JSON numbers with integer struct fields. Other
+// arguments fail the template.
+func add(a, b interface{}) (float64, error) {
+	x, y, err := numberArgs(a, b)
+	return x + y, err
+}
+
+func sub(a, b interface{}) (float64, error) {
+	x, y, err := numberArgs(a, b)
+	return x - y, err
+}
+
+func mul(a, b interface{}) (float64, error) {
+	x, y, err := numberArgs(a, b)
+	return x * y, err
+}
+
+func div(a, b interface{}) (float64, error) {
+	x, y, err := numberArgs(a, b)
+	if err != nil || y == 0 {
+		return 0, err
+	}
+	return x / y, nil
+}
+
+func numberArgs(a, b interface{}) (float64, float64, error) {
+	x, err := numberArg(a)
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := numberArg(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	return x, y, nil
+}
+
+func numberArg(value interface{}) (float64, error) {
+	number, ok := toFloat64(value)
+	if !ok {
+		return 0, fmt.Errorf("non-numeric argument %v", value)
 	}
-	return a / b
+	return number, nil
 }
 
 func ifElse(condition interface{}, trueValue, falseValue interface{}) interface{} {
@@ -736,7 +1167,7 @@ func formatCurrencyValue(value interface{}, symbol ...string) string {
 	if len(symbol) > 0 && symbol[0] != "" {
 		prefix = symbol[0]
 	}
-	return prefix + formatNumberValue(value, 2)
+	return currencyString(prefix, formatNumberValue(value, 2))
 }
 
 func formatPercentValue(value interface{}, decimals ...int) string {
@@ -0,0 +1,221 @@
+// Package engine provides footnote functionality.
+package engine
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+const (
+	// footnoteMarkerScale is the marker font size relative to the text.
+	footnoteMarkerScale = 0.65
+	// footnoteSeparatorSpace is the space above the first note on a page,
+	// which holds the separator line.
+	footnoteSeparatorSpace = 4.0
+	footnoteFontSize       = 8.0
+	footnoteLineHeight     = 3.5
+)
+
+// footnote is a numbered note drawn at the bottom of the page that
+// references it.
+type footnote struct {
+	number int
+	text   string
+	style  string
+	family string
+}
+
+// footnoteMarker lays out the marker of a footnote run, in the current run
+// format, as the given number.
+func (e *Engine) footnoteMarker(run *models.TextRun, number int, family string) richFragment {
+	size, _ := e.pdf.GetFontSize()
+	e.pdf.SetFontSize(size * footnoteMarkerScale)
+	defer e.pdf.SetFontSize(size)
+
+	note, style := run.Footnote, run.FootnoteStyle
+	if run.FootnoteRef != "" {
+		declared := e.declaredFootnote(run.FootnoteRef)
+		if declared == nil {
+			e.undefinedFootnotes[run.FootnoteRef] = true
+		} else {
+			note = strings.TrimSpace(declared.Content)
+			if style == "" {
+				style = declared.Style
+			}
+		}
+	}
+
+	text := strconv.Itoa(number)
+	return richFragment{
+		text:   text,
+		format: run,
+		width:  e.pdf.GetStringWidth(text),
+		note: &footnote{
+			number: number,
+			text:   e.processTemplate(note),
+			style:  style,
+			family: family,
+		},
+	}
+}
+
+// declaredFootnote returns the footnote declared with id, or nil.
+func (e *Engine) declaredFootnote(id string) *models.Footnote {
+	if e.report.Footnotes == nil {
+		return nil
+	}
+	for idx := range e.report.Footnotes.Footnotes {
+		if note := &e.report.Footnotes.Footnotes[idx]; note.ID == id {
+			return note
+		}
+	}
+	return nil
+}
+
+// checkFootnotes reports footnote markers that refer to no declared note.
+func (e *Engine) checkFootnotes() error {
+	if len(e.undefinedFootnotes) == 0 {
+		return nil
+	}
+	undefined := slices.Sorted(maps.Keys(e.undefinedFootnotes))
+	return fmt.Errorf("undefined footnote %q", undefined[0])
+}
+
+// drawFootnoteMarker draws a marker raised above the baseline of the line at
+// y, in a smaller size of the current font.
+func (e *Engine) drawFootnoteMarker(fragment richFragment, x, y, lineHeight float64) {
+	size, unitSize := e.pdf.GetFontSize()
+	e.pdf.SetFontSize(size * footnoteMarkerScale)
+	e.pdf.SetXY(x, y-unitSize*0.3)
+	e.pdf.CellFormat(fragment.width, lineHeight, fragment.text, "", 0, "L", false, 0, "")
+}
+
+// notes returns the footnotes marked on the line.
+func (l richLine) notes() []*footnote {
+	var notes []*footnote
+	for _, fragment := range l.fragments {
+		if fragment.note != nil {
+			notes = append(notes, fragment.note)
+		}
+	}
+	return notes
+}
+
+// footnotesHeight returns the space that notes take at the bottom of the
+// current page, including the separator if they are the first there.
+func (e *Engine) footnotesHeight(notes []*footnote) float64 {
+	if len(notes) == 0 {
+		return 0
+	}
+
+	height := 0.0
+	if len(e.pageFootnotes) == 0 {
+		height = footnoteSeparatorSpace
+	}
+	e.withGraphicsState(func() {
+		for _, note := range notes {
+			lineHeight := e.applyFootnoteFormat(note)
+			numberWidth := e.footnoteNumberWidth(note)
+			lines := len(e.pdf.SplitText(note.text, e.footnoteWidth()-numberWidth))
+			if lines == 0 {
+				lines = 1
+			}
+			height += float64(lines) * lineHeight
+		}
+	})
+	return height
+}
+
+// addFootnotes adds notes to the current page and raises the page break
+// trigger above them, so the following content stops short of the notes.
+func (e *Engine) addFootnotes(notes []*footnote) {
+	if len(notes) == 0 {
+		return
+	}
+
+	autoPageBreak, bottomMargin := e.pdf.GetAutoPageBreak()
+	if len(e.pageFootnotes) == 0 {
+		e.footnoteMargin = bottomMargin
+		e.footnoteReserved = 0
+	}
+	e.footnoteReserved += e.footnotesHeight(notes)
+	for _, note := range notes {
+		e.pageFootnotes = append(e.pageFootnotes, *note)
+		e.footnoteCount = note.number
+	}
+	e.pdf.SetAutoPageBreak(autoPageBreak, e.footnoteMargin+e.footnoteReserved)
+}
+
+// drawFootnotes draws the notes of the current page in the space reserved
+// above the bottom margin, then releases it for the next page.
+func (e *Engine) drawFootnotes() {
+	if len(e.pageFootnotes) == 0 {
+		return
+	}
+
+	// A paragraph breaking across pages has already set the margin of the
+	// next page.
+	autoPageBreak, bottomMargin := e.pdf.GetAutoPageBreak()
+	if !e.lineBreaksActive {
+		bottomMargin = e.footnoteMargin
+	}
+	e.pdf.SetAutoPageBreak(false, bottomMargin)
+	defer e.pdf.SetAutoPageBreak(autoPageBreak, bottomMargin)
+
+	left, _, _, _ := e.pdf.GetMargins()
+	_, pageHeight := e.pdf.GetPageSize()
+	y := pageHeight - e.footnoteMargin - e.footnoteReserved
+
+	e.withGraphicsState(func() {
+		e.pdf.SetDrawColor(0, 0, 0)
+		e.pdf.SetLineWidth(0.2)
+		e.pdf.Line(left, y+footnoteSeparatorSpace/2, left+e.footnoteWidth()/3, y+footnoteSeparatorSpace/2)
+		y += footnoteSeparatorSpace
+
+		for idx := range e.pageFootnotes {
+			note := &e.pageFootnotes[idx]
+			lineHeight := e.applyFootnoteFormat(note)
+			numberWidth := e.footnoteNumberWidth(note)
+
+			e.pdf.SetXY(left, y)
+			e.pdf.CellFormat(numberWidth, lineHeight, strconv.Itoa(note.number), "", 0, "L", false, 0, "")
+			e.pdf.SetXY(left+numberWidth, y)
+			e.pdf.MultiCell(e.footnoteWidth()-numberWidth, lineHeight, note.text, "", "L", false)
+			y = e.pdf.GetY()
+		}
+	})
+
+	e.pageFootnotes = nil
+	e.footnoteReserved = 0
+}
+
+// applyFootnoteFormat sets the note font and returns its line height.
+func (e *Engine) applyFootnoteFormat(note *footnote) float64 {
+	if style, ok := e.styles[note.style]; ok {
+		e.applyStyle(note.style)
+		if style.LineHeight > 0 {
+			return style.LineHeight
+		}
+		return footnoteLineHeight
+	}
+	e.setFont(note.family, "", footnoteFontSize)
+	e.pdf.SetTextColor(0, 0, 0)
+	return footnoteLineHeight
+}
+
+// footnoteNumberWidth returns the width of the column holding note numbers.
+func (e *Engine) footnoteNumberWidth(note *footnote) float64 {
+	return e.pdf.GetStringWidth(strconv.Itoa(note.number)) + 2*e.pdf.GetCellMargin() + 1
+}
+
+// footnoteWidth returns the width of the note area between the page margins.
+func (e *Engine) footnoteWidth() float64 {
+	left, _, right, _ := e.pdf.GetMargins()
+	pageWidth, _ := e.pdf.GetPageSize()
+	return pageWidth - left - right
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+func TestRenderTextPlacesFootnotesAtPageBottom(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Year": "2025"})
+	engine.setupHeaderFooter()
+
+	engine.renderText(&models.Text{
+		Style: "body",
+		Wrap:  true,
+		Runs: []models.TextRun{
+			{Content: "Revenue grew"},
+			{Footnote: "Unaudited {{.Year}} figures."},
+			{Content: " and margins held"},
+			{Footnote: "Excludes one-off items."},
+			{Content: "."},
+		},
+	})
+
+	if engine.footnoteCount != 2 || len(engine.pageFootnotes) != 2 {
+		t.Fatalf("expected two numbered footnotes on the page, got %d and %#v", engine.footnoteCount, engine.pageFootnotes)
+	}
+	if _, bottomMargin := engine.pdf.GetAutoPageBreak(); bottomMargin <= 10 {
+		t.Fatalf("expected footnote space to be reserved, got bottom margin %v", bottomMargin)
+	}
+
+	output := renderedPDF(t, engine)
+	for _, want := range []string{"(Unaudited 2025 figures.)", "(Excludes one-off items.)", "(1)", "(2)"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %s in output", want)
+		}
+	}
+	if textPosition(t, output, "1") <= textPosition(t, output, "grew") {
+		t.Fatalf("expected marker after the referencing word")
+	}
+}
+
+func TestRenderTextResolvesDeclaredFootnotes(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Year": "2025"})
+	engine.setupHeaderFooter()
+	engine.report.Footnotes = &models.Footnotes{Footnotes: []models.Footnote{
+		{ID: "unaudited", Content: " Unaudited {{.Year}} figures. "},
+	}}
+
+	engine.renderText(&models.Text{
+		Style: "body",
+		Wrap:  true,
+		Runs: []models.TextRun{
+			{Content: "Revenue"},
+			{FootnoteRef: "unaudited"},
+			{Content: " and costs"},
+			{FootnoteRef: "unaudited"},
+		},
+	})
+
+	if len(engine.pageFootnotes) != 2 {
+		t.Fatalf("expected a numbered note for each marker, got %#v", engine.pageFootnotes)
+	}
+	for _, note := range engine.pageFootnotes {
+		if note.text != "Unaudited 2025 figures." {
+			t.Fatalf("expected the declared note text, got %q", note.text)
+		}
+	}
+	if err := engine.checkFootnotes(); err != nil {
+		t.Fatalf("checkFootnotes returned error: %v", err)
+	}
+}
+
+func TestRenderSectionsRejectsUndefinedFootnote(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	engine.report.Sections.Sections = []models.Section{{
+		Name: "summary",
+		Elements: []models.SectionElement{
+			{Type: "text", Text: &models.Text{Runs: []models.TextRun{{Content: "Revenue"}, {FootnoteRef: "missing"}}}},
+		},
+	}}
+
+	err := engine.renderSections()
+	if err == nil || !strings.Contains(err.Error(), `undefined footnote "missing"`) {
+		t.Fatalf("expected undefined footnote error, got %v", err)
+	}
+}
+
+func TestRenderTextMovesLineWhenFootnoteDoesNotFit(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	engine.setupHeaderFooter()
+
+	// The line itself fits above the bottom margin, but its note does not.
+	engine.pdf.SetY(engine.pageBreakTrigger() - 6)
+	engine.renderText(&models.Text{
+		Style: "body",
+		Runs: []models.TextRun{
+			{Content: "See note"},
+			{Footnote: "A note that needs room."},
+		},
+	})
+
+	if engine.pdf.PageNo() != 2 {
+		t.Fatalf("expected the line to move to page 2, got page %d", engine.pdf.PageNo())
+	}
+	if len(engine.pageFootnotes) != 1 || engine.pageFootnotes[0].number != 1 {
+		t.Fatalf("expected the note on the new page, got %#v", engine.pageFootnotes)
+	}
+}
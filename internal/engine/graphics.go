@@ -0,0 +1,188 @@
+// Package engine provides sparkline and progress bar rendering functionality.
+package engine
+
+import (
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+const (
+	// defaultInlineGraphicWidth is the width of a standalone sparkline or
+	// progress bar when none is set.
+	defaultInlineGraphicWidth = 40.0
+
+	defaultSparklineHeight   = 8.0
+	defaultProgressBarHeight = 4.0
+)
+
+var (
+	defaultGraphicColor       = models.RGBColor{R: 51, G: 102, B: 153}
+	defaultProgressBackground = models.RGBColor{R: 230, G: 230, B: 230}
+)
+
+// renderSparkline draws a sparkline element at the current flow position,
+// starting a new page when it does not fit unless it is a row child.
+func (e *Engine) renderSparkline(sparkline *models.Sparkline) {
+	width := e.inlineGraphicWidth(sparkline.Width)
+	height := sparkline.Height
+	if height <= 0 {
+		height = defaultSparklineHeight
+	}
+
+	if !e.inRow && !e.fitsOnPage(height) {
+		e.pdf.AddPage()
+	}
+	e.defineAnchor(sparkline.Anchor)
+
+	x := e.flowLeftMargin()
+	y := e.pdf.GetY()
+	values := sparklineValues(e.resolveDataItems(sparkline.DataSource), sparkline.ValueField)
+	e.drawSparkline(values, x, y, width, height, sparkline.Color, sparkline.LineWidth)
+
+	e.pdf.SetXY(x, y+height)
+	if sparkline.SpacingAfter > 0 {
+		e.pdf.Ln(sparkline.SpacingAfter)
+	}
+}
+
+// renderProgressBar draws a progress bar element at the current flow
+// position, starting a new page when it does not fit unless it is a row
+// child.
+func (e *Engine) renderProgressBar(progress *models.ProgressBar) {
+	width := e.inlineGraphicWidth(progress.Width)
+	height := progress.Height
+	if height <= 0 {
+		height = defaultProgressBarHeight
+	}
+
+	if !e.inRow && !e.fitsOnPage(height) {
+		e.pdf.AddPage()
+	}
+	e.defineAnchor(progress.Anchor)
+
+	x := e.flowLeftMargin()
+	y := e.pdf.GetY()
+	value, _ := toFloat64(e.processTemplate(progress.Value))
+	e.drawProgressBar(progressFraction(value, progress.Max), x, y, width, height, progress.Color, progress.BackgroundColor)
+
+	e.pdf.SetXY(x, y+height)
+	if progress.SpacingAfter > 0 {
+		e.pdf.Ln(progress.SpacingAfter)
+	}
+}
+
+// inlineGraphicWidth returns the width of a standalone graphic, capped to
+// the current flow width.
+func (e *Engine) inlineGraphicWidth(width float64) float64 {
+	if width <= 0 {
+		width = defaultInlineGraphicWidth
+	}
+	if flowWidth := e.flowContentWidth(); width > flowWidth {
+		width = flowWidth
+	}
+	return width
+}
+
+// sparklineValues converts data items to numbers, reading field from each
+// item when set. Non-numeric values count as zero.
+func sparklineValues(items []interface{}, field string) []float64 {
+	values := make([]float64, len(items))
+	for idx, item := range items {
+		if field != "" {
+			item, _ = resolveDataPath(item, field)
+		}
+		values[idx], _ = toFloat64(item)
+	}
+	return values
+}
+
+// progressFraction returns value as a fraction of max (default: 1), clamped
+// to the range 0 to 1.
+func progressFraction(value, max float64) float64 {
+	if max <= 0 {
+		max = 1
+	}
+
+	fraction := value / max
+	if fraction < 0 {
+		return 0
+	}
+	if fraction > 1 {
+		return 1
+	}
+	return fraction
+}
+
+// drawSparkline scales values into the box and draws them as a polyline
+// with a dot on the last value. A flat series runs through the middle.
+func (e *Engine) drawSparkline(values []float64, x, y, width, height float64, color string, lineWidth float64) {
+	if len(values) == 0 || width <= 0 || height <= 0 {
+		return
+	}
+
+	r, g, b := graphicColor(color, defaultGraphicColor)
+	if lineWidth <= 0 {
+		lineWidth = 0.3
+	}
+
+	e.withGraphicsState(func() {
+		e.pdf.SetDrawColor(r, g, b)
+		e.pdf.SetFillColor(r, g, b)
+		e.pdf.SetLineWidth(lineWidth)
+
+		low, high := values[0], values[0]
+		for _, value := range values {
+			if value < low {
+				low = value
+			}
+			if value > high {
+				high = value
+			}
+		}
+
+		pointY := func(value float64) float64 {
+			if high == low {
+				return y + height/2
+			}
+			return y + height - (value-low)/(high-low)*height
+		}
+
+		step := 0.0
+		if len(values) > 1 {
+			step = width / float64(len(values)-1)
+		}
+
+		previousX, previousY := x, pointY(values[0])
+		for idx, value := range values[1:] {
+			pointX := x + float64(idx+1)*step
+			currentY := pointY(value)
+			e.pdf.Line(previousX, previousY, pointX, currentY)
+			previousX, previousY = pointX, currentY
+		}
+		e.pdf.Circle(previousX, previousY, lineWidth*2, "F")
+	})
+}
+
+// drawProgressBar draws a background bar with the given fraction filled in.
+func (e *Engine) drawProgressBar(fraction, x, y, width, height float64, color, background string) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	e.withGraphicsState(func() {
+		e.pdf.SetFillColor(graphicColor(background, defaultProgressBackground))
+		e.pdf.Rect(x, y, width, height, "F")
+
+		if fraction > 0 {
+			e.pdf.SetFillColor(graphicColor(color, defaultGraphicColor))
+			e.pdf.Rect(x, y, width*fraction, height, "F")
+		}
+	})
+}
+
+// graphicColor parses a color attribute, falling back when it is empty.
+func graphicColor(color string, fallback models.RGBColor) (int, int, int) {
+	if color == "" {
+		return fallback.R, fallback.G, fallback.B
+	}
+	return models.ParseColor(color)
+}
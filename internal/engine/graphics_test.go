@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+func TestProgressFraction(t *testing.T) {
+	tests := []struct {
+		value, max, want float64
+	}{
+		{0.25, 0, 0.25},
+		{30, 120, 0.25},
+		{150, 100, 1},
+		{-5, 100, 0},
+	}
+
+	for _, tt := range tests {
+		if got := progressFraction(tt.value, tt.max); got != tt.want {
+			t.Fatalf("progressFraction(%v, %v) = %v, want %v", tt.value, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestSparklineValuesReadsItemsAndFields(t *testing.T) {
+	if got := sparklineValues([]interface{}{1, "2.5", "n/a"}, ""); len(got) != 3 || got[1] != 2.5 || got[2] != 0 {
+		t.Fatalf("expected plain items to convert to numbers, got %v", got)
+	}
+
+	items := []interface{}{
+		map[string]interface{}{"close": 10.0},
+		map[string]interface{}{"close": 12.0},
+	}
+	if got := sparklineValues(items, "close"); len(got) != 2 || got[0] != 10 || got[1] != 12 {
+		t.Fatalf("expected field values, got %v", got)
+	}
+}
+
+func TestRenderTableDrawsSparklineAndProgressColumns(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Metrics": []interface{}{
+		map[string]interface{}{"name": "Revenue", "history": []float64{3, 5, 4, 8}, "target": 0.8},
+		map[string]interface{}{"name": "Churn", "history": []interface{}{2, 1, 1}, "target": 0.35},
+	}})
+
+	startY := engine.pdf.GetY()
+	engine.renderTable(&models.Table{
+		DataSource: "{{.Metrics}}",
+		Border:     true,
+		Columns: models.Columns{Columns: []models.Column{
+			{Header: "Metric", Field: "name", Width: "40"},
+			{Header: "Trend", Field: "history", Width: "30", Type: "sparkline", Color: "#FF0000"},
+			{Header: "Target", Field: "target", Width: "30", Type: "progressBar", Color: "#00FF00"},
+		}},
+	})
+
+	if got, want := engine.pdf.GetY(), startY+3*defaultTableRowHeight; got != want {
+		t.Fatalf("expected graphic cells to keep the default row height (Y %.2f), got %.2f", want, got)
+	}
+
+	output := renderedPDF(t, engine)
+	if !strings.Contains(output, "1.000 0.000 0.000 RG") {
+		t.Fatalf("expected sparkline stroke color in output")
+	}
+	if !strings.Contains(output, "0.000 1.000 0.000 rg") {
+		t.Fatalf("expected progress bar fill color in output")
+	}
+	if strings.Contains(output, "[3 5 4 8]") {
+		t.Fatalf("expected sparkline column not to print its raw values")
+	}
+}
+
+func TestRenderRowPlacesSparklineAndProgressBar(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"History": []int{1, 4, 2}, "Done": 42})
+
+	startY := engine.pdf.GetY()
+	err := engine.renderRow(&models.Row{Elements: []models.SectionElement{
+		{Type: "sparkline", Sparkline: &models.Sparkline{DataSource: "{{.History}}", Width: 30, Height: 10}},
+		{Type: "progressBar", Progress: &models.ProgressBar{Value: "{{.Done}}", Max: 100, Width: 40}},
+	}})
+	if err != nil {
+		t.Fatalf("renderRow returned error: %v", err)
+	}
+
+	if got, want := engine.pdf.GetY(), startY+10; got != want {
+		t.Fatalf("expected row to grow to the sparkline height (Y %.2f), got %.2f", want, got)
+	}
+
+	err = engine.renderRow(&models.Row{Elements: []models.SectionElement{
+		{Type: "progressBar", Progress: &models.ProgressBar{Value: "1"}},
+	}})
+	if err == nil {
+		t.Fatalf("expected row progress bar without width to be rejected")
+	}
+}
+
+func TestRenderRowKeepsGraphicsOnRowPage(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"History": []int{1, 4, 2}})
+	engine.pdf.SetY(engine.pageBreakTrigger() - 6)
+
+	err := engine.renderRow(&models.Row{Elements: []models.SectionElement{
+		{Type: "text", Text: &models.Text{Style: "body", Width: 40, Content: "Trend"}},
+		{Type: "sparkline", Sparkline: &models.Sparkline{DataSource: "{{.History}}", Width: 30, Height: 10}},
+		{Type: "progressBar", Progress: &models.ProgressBar{Value: "0.5", Width: 40, Height: 8}},
+	}})
+	if err != nil {
+		t.Fatalf("renderRow returned error: %v", err)
+	}
+
+	if engine.pdf.PageNo() != 1 {
+		t.Fatalf("expected the graphics to stay beside the text on page 1, got page %d", engine.pdf.PageNo())
+	}
+}
@@ -0,0 +1,323 @@
+// Package engine provides pagination control functionality.
+package engine
+
+import (
+	"maps"
+	"math"
+
+	"github.com/dannyswat/reportgo/internal/models"
+	"github.com/phpdave11/gofpdf"
+)
+
+// scratchPageHeights is the height of a scratch page in report pages, enough
+// for blocks to be measured without page breaks.
+const scratchPageHeights = 100
+
+// measureHeight returns the height that render draws from the current
+// position, or infinity when render starts a new page itself.
+func (e *Engine) measureHeight(render func() error) (float64, error) {
+	y := e.pdf.GetY()
+	pageEnds, err := e.measureLayout(render, false)
+	if err != nil {
+		return 0, err
+	}
+	if len(pageEnds) > 1 {
+		return math.Inf(1), nil
+	}
+	return pageEnds[0] - y, nil
+}
+
+// measureLayout runs render from the current position on a scratch page,
+// then restores the engine, so nothing is drawn in the report. It returns
+// the Y position render reaches on each page it draws on. With paginate set,
+// scratch pages break like report pages; otherwise the scratch page is too
+// tall to break.
+func (e *Engine) measureLayout(render func() error, paginate bool) ([]float64, error) {
+	// A dry run within a dry run needs a document of its own, kept for the
+	// next dry run at the same depth.
+	depth := e.measureDepth
+	if depth == len(e.scratches) {
+		e.scratches = append(e.scratches, nil)
+	}
+	if e.scratches[depth] == nil {
+		e.scratches[depth] = e.newScratch()
+	}
+	scratch := e.scratches[depth]
+
+	x, y := e.pdf.GetXY()
+	left, top, right, _ := e.pdf.GetMargins()
+	autoPageBreak, bottom := e.pdf.GetAutoPageBreak()
+	pageWidth, pageHeight := e.pdf.GetPageSize()
+	fontSize, _ := e.pdf.GetFontSize()
+	r, g, b := e.pdf.GetTextColor()
+
+	scratch.SetMargins(left, top, right)
+	scratch.SetAutoPageBreak(autoPageBreak, bottom)
+	if paginate {
+		scratch.AddPageFormat("P", gofpdf.SizeType{Wd: pageWidth, Ht: pageHeight})
+	} else {
+		scratch.AddPageFormat("P", gofpdf.SizeType{Wd: pageWidth, Ht: y + scratchPageHeights*pageHeight})
+	}
+	scratch.SetCellMargin(e.pdf.GetCellMargin())
+	scratch.SetFont(e.fontFamily, e.fontStyle, fontSize)
+	scratch.SetTextColor(r, g, b)
+	scratch.SetXY(x, y)
+
+	saved := *e
+	defer func() {
+		scratches := e.scratches
+		*e = saved
+		e.scratches = scratches
+		if scratch.Err() {
+			// A failed document stays failed, so start over next time.
+			e.scratches[depth] = nil
+		}
+	}()
+	e.pdf = scratch
+	e.measuring = true
+	e.measureDepth = depth + 1
+	e.paginating = paginate
+	e.scratchPageEnds = nil
+	e.anchors = maps.Clone(e.anchors)
+	e.anchorPages = maps.Clone(e.anchorPages)
+	e.pageRefs = maps.Clone(e.pageRefs)
+	e.tocLinks = maps.Clone(e.tocLinks)
+
+	if err := render(); err != nil {
+		return nil, err
+	}
+	return append(e.scratchPageEnds, scratch.GetY()), nil
+}
+
+// newScratch returns a document for dry runs with the report's default page
+// and fonts. Its footer records where each page ends.
+func (e *Engine) newScratch() *gofpdf.Fpdf {
+	doc := e.report.Document
+	scratch := gofpdf.New(string(doc.Orientation[0:1]), doc.Unit, doc.Format, "")
+	for _, font := range e.fonts {
+		scratch.AddUTF8FontFromBytes(font.Family, font.Style, font.Data)
+	}
+	scratch.SetAcceptPageBreakFunc(e.acceptPageBreak)
+	scratch.SetFooterFunc(func() {
+		// Starting a dry run ends the previous one's page too.
+		if e.pdf == scratch {
+			e.scratchPageEnds = append(e.scratchPageEnds, scratch.GetY())
+		}
+	})
+	return scratch
+}
+
+// pageContentHeight returns the height available for content on an empty
+// page.
+func (e *Engine) pageContentHeight() float64 {
+	_, top, _, _ := e.pdf.GetMargins()
+	return e.pageBreakTrigger() - top
+}
+
+// keepTogether starts a new page when the block drawn by render does not fit
+// on the rest of the current page but would fit on an empty one. It does not
+// draw the block.
+func (e *Engine) keepTogether(render func() error) error {
+	if e.measuring && !e.paginating {
+		return nil
+	}
+
+	height, err := e.measureHeight(render)
+	if err != nil {
+		return err
+	}
+	if !e.fitsOnPage(height) && height <= e.pageContentHeight() {
+		e.pdf.AddPage()
+	}
+	return nil
+}
+
+// keepWithNext starts a new page when the text at the start of elements,
+// the texts marked keepWithNext directly after it and the start of the next
+// element do not fit on the rest of the current page. The start of the next
+// element is all of it, up to a quarter of a page.
+func (e *Engine) keepWithNext(elements []models.SectionElement) error {
+	if e.measuring && !e.paginating {
+		return nil
+	}
+
+	var group []models.SectionElement
+	var next *models.SectionElement
+	for idx := range elements {
+		elem := elements[idx]
+		if !e.shouldRenderCondition(e.getElementCondition(elem)) {
+			continue
+		}
+		if elem.Type == "text" && elem.Text != nil && elem.Text.KeepWithNext {
+			group = append(group, elem)
+			continue
+		}
+		next = &elements[idx]
+		break
+	}
+	if next == nil || next.Type == "pageBreak" {
+		return nil
+	}
+
+	var groupHeight float64
+	height, err := e.measureHeight(func() error {
+		start := e.pdf.GetY()
+		for _, elem := range group {
+			if err := e.renderElement(elem); err != nil {
+				return err
+			}
+		}
+		groupHeight = e.pdf.GetY() - start
+		return e.renderElement(*next)
+	})
+	if err != nil {
+		return err
+	}
+
+	required := groupHeight + math.Min(height-groupHeight, e.pageContentHeight()/4)
+	if !e.fitsOnPage(required) && required <= e.pageContentHeight() {
+		e.pdf.AddPage()
+	}
+	return nil
+}
+
+// textMinLines returns the orphan and widow settings of a text, from the
+// element or else its style.
+func (e *Engine) textMinLines(text *models.Text) (before, after int) {
+	if style, ok := e.styles[text.Style]; ok {
+		before, after = style.MinLinesBefore, style.MinLinesAfter
+	}
+	if text.MinLinesBefore > 0 {
+		before = text.MinLinesBefore
+	}
+	if text.MinLinesAfter > 0 {
+		after = text.MinLinesAfter
+	}
+	return before, after
+}
+
+// planLineBreaks returns the indexes of the lines, with the given heights,
+// that start a new page when a paragraph is drawn from the current position.
+// Breaks move up so at least minAfter lines start the last page, and the
+// whole paragraph moves to the next page when fewer than minBefore lines
+// would end the first. Index 0 means the paragraph starts on a new page.
+func (e *Engine) planLineBreaks(heights []float64, minBefore, minAfter int) []int {
+	if autoPageBreak, _ := e.pdf.GetAutoPageBreak(); !autoPageBreak || minBefore <= 1 && minAfter <= 1 {
+		return nil
+	}
+
+	_, top, _, _ := e.pdf.GetMargins()
+	trigger := e.pageBreakTrigger()
+	y := e.pdf.GetY()
+	// Moving lines to a new page does not help when they start one.
+	fresh := math.Abs(y-top) < 0.001
+
+	var breaks []int
+	for start := 0; ; {
+		natural := start
+		for natural < len(heights) && y+heights[natural] <= trigger {
+			y += heights[natural]
+			natural++
+		}
+		if natural == len(heights) {
+			return breaks
+		}
+
+		end := natural
+		if len(heights)-end < minAfter {
+			end = len(heights) - minAfter
+		}
+		if start == 0 && end < minBefore {
+			end = 0
+		}
+		if end <= start && (start > 0 || fresh) {
+			end = max(natural, start+1)
+		}
+
+		breaks = append(breaks, end)
+		start = end
+		y = top
+		fresh = true
+	}
+}
+
+// multiCellWithBreaks draws wrapped plain text like MultiCell, starting new
+// pages before the lines planned by planLineBreaks, and returns the page and
+// Y position of the first line. It lowers the page break trigger to end each
+// page early and restores it afterwards.
+func (e *Engine) multiCellWithBreaks(width, lineHeight float64, content, align string, minBefore, minAfter int) (int, float64) {
+	lines := e.pdf.SplitText(content, width)
+	heights := make([]float64, len(lines))
+	for idx := range heights {
+		heights[idx] = lineHeight
+	}
+
+	breaks := e.planLineBreaks(heights, minBefore, minAfter)
+	if len(breaks) > 0 && breaks[0] == 0 {
+		x := e.pdf.GetX()
+		e.pdf.AddPage()
+		e.pdf.SetX(x)
+		breaks = breaks[1:]
+	}
+	startPage, startY := e.pdf.PageNo(), e.pdf.GetY()
+	if len(breaks) == 0 {
+		e.pdf.MultiCell(width, lineHeight, content, "", align, false)
+		return startPage, startY
+	}
+
+	_, top, _, _ := e.pdf.GetMargins()
+	start, y := 0, e.pdf.GetY()
+	e.lineBreakTriggers = nil
+	for _, end := range breaks {
+		e.lineBreakTriggers = append(e.lineBreakTriggers, y+float64(end-start)*lineHeight)
+		start, y = end, top
+	}
+
+	// Later pages start without this page's footnotes.
+	autoPageBreak, bottomMargin := e.pdf.GetAutoPageBreak()
+	e.lineBreakMargin = bottomMargin
+	if len(e.pageFootnotes) > 0 {
+		e.lineBreakMargin = e.footnoteMargin
+	}
+	e.lineBreaksActive = true
+	e.nextLineBreakTrigger()
+	page := e.pdf.PageNo()
+	defer func() {
+		e.lineBreaksActive = false
+		e.lineBreakTriggers = nil
+		if e.pdf.PageNo() == page {
+			e.pdf.SetAutoPageBreak(autoPageBreak, bottomMargin)
+		} else {
+			e.pdf.SetAutoPageBreak(autoPageBreak, e.lineBreakMargin)
+		}
+	}()
+
+	e.pdf.MultiCell(width, lineHeight, content, "", align, false)
+	return startPage, startY
+}
+
+// acceptPageBreak is the gofpdf page break callback. It moves on to the next
+// planned page end of multiCellWithBreaks.
+func (e *Engine) acceptPageBreak() bool {
+	if e.lineBreaksActive {
+		e.nextLineBreakTrigger()
+	}
+	autoPageBreak, _ := e.pdf.GetAutoPageBreak()
+	return autoPageBreak
+}
+
+// nextLineBreakTrigger moves the page break trigger to the next planned page
+// end of multiCellWithBreaks, or restores it after the last one.
+func (e *Engine) nextLineBreakTrigger() {
+	autoPageBreak, _ := e.pdf.GetAutoPageBreak()
+	if len(e.lineBreakTriggers) == 0 {
+		e.pdf.SetAutoPageBreak(autoPageBreak, e.lineBreakMargin)
+		return
+	}
+
+	_, pageHeight := e.pdf.GetPageSize()
+	// The margin sits just below the last line so rounding cannot push
+	// that line over.
+	e.pdf.SetAutoPageBreak(autoPageBreak, pageHeight-e.lineBreakTriggers[0]-0.001)
+	e.lineBreakTriggers = e.lineBreakTriggers[1:]
+}
@@ -0,0 +1,230 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+func keepTestRows() []interface{} {
+	var rows []interface{}
+	for idx := 0; idx < 6; idx++ {
+		rows = append(rows, map[string]interface{}{"name": "Row"})
+	}
+	return rows
+}
+
+func keepTestTable() *models.Table {
+	return &models.Table{
+		BaseElement: models.BaseElement{KeepTogether: true},
+		DataSource:  "{{.Rows}}",
+		Columns:     models.Columns{Columns: []models.Column{{Header: "Name", Field: "name", Width: "50"}}},
+	}
+}
+
+func TestKeepTogetherMovesTableToNextPage(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": keepTestRows()})
+	table := keepTestTable()
+	height, err := engine.measureHeight(func() error { return engine.renderElement(models.SectionElement{Type: "table", Table: table}) })
+	if err != nil {
+		t.Fatalf("measureHeight returned error: %v", err)
+	}
+	engine.pdf.SetY(engine.pageBreakTrigger() - height/2)
+
+	if err := engine.renderElements([]models.SectionElement{{Type: "table", Table: table}}); err != nil {
+		t.Fatalf("renderElements returned error: %v", err)
+	}
+
+	if engine.pdf.PageNo() != 2 {
+		t.Fatalf("expected the table on page 2, got page %d", engine.pdf.PageNo())
+	}
+	if _, top, _, _ := engine.pdf.GetMargins(); math.Abs(engine.pdf.GetY()-(top+height)) > 0.001 {
+		t.Fatalf("expected the whole table at the top of page 2, ended at %v", engine.pdf.GetY())
+	}
+}
+
+func TestKeepTogetherLeavesFittingBlockInPlace(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": keepTestRows()})
+
+	err := engine.renderElements([]models.SectionElement{{Type: "table", Table: keepTestTable()}})
+	if err != nil {
+		t.Fatalf("renderElements returned error: %v", err)
+	}
+
+	if engine.pdf.PageNo() != 1 {
+		t.Fatalf("expected the table to stay on page 1, got page %d", engine.pdf.PageNo())
+	}
+	if engine.measuring || engine.measureDepth != 0 || engine.pdf == engine.scratches[0] {
+		t.Fatalf("expected the engine to be restored after measuring")
+	}
+}
+
+func TestKeepWithNextMovesHeadingWithTable(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": keepTestRows()})
+	engine.pdf.SetY(engine.pageBreakTrigger() - 12)
+
+	table := keepTestTable()
+	table.KeepTogether = false
+	err := engine.renderElements([]models.SectionElement{
+		{Type: "text", Text: &models.Text{Style: "body", Content: "Heading", KeepWithNext: true, TocEntry: "true"}},
+		{Type: "table", Table: table},
+	})
+	if err != nil {
+		t.Fatalf("renderElements returned error: %v", err)
+	}
+
+	if len(engine.tocCollected) != 1 || engine.tocCollected[0].page != 2 {
+		t.Fatalf("expected the heading on page 2, got %#v", engine.tocCollected)
+	}
+}
+
+func TestMeasureHeightRestoresEngineState(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	y := engine.pdf.GetY()
+
+	height, err := engine.measureHeight(func() error {
+		engine.defineAnchor("measured")
+		return engine.renderElement(models.SectionElement{Type: "spacer", Spacer: &models.Spacer{Height: 30}})
+	})
+	if err != nil {
+		t.Fatalf("measureHeight returned error: %v", err)
+	}
+
+	if math.Abs(height-30) > 0.001 {
+		t.Fatalf("expected measured height 30, got %v", height)
+	}
+	if engine.pdf.GetY() != y || engine.pdf.PageNo() != 1 {
+		t.Fatalf("expected the report position to be unchanged")
+	}
+	if _, ok := engine.anchorPages["measured"]; ok {
+		t.Fatalf("expected anchors defined while measuring to be discarded")
+	}
+}
+
+func TestMeasureHeightReusesScratchDocumentsPerDepth(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	spacer := models.SectionElement{Type: "spacer", Spacer: &models.Spacer{Height: 10}}
+	nested := func() error {
+		for range 2 {
+			height, err := engine.measureHeight(func() error { return engine.renderElement(spacer) })
+			if err != nil {
+				return err
+			}
+			if math.Abs(height-10) > 0.001 {
+				return fmt.Errorf("expected nested height 10, got %v", height)
+			}
+		}
+		return nil
+	}
+
+	if _, err := engine.measureHeight(nested); err != nil {
+		t.Fatalf("measureHeight returned error: %v", err)
+	}
+	scratches := slices.Clone(engine.scratches)
+	if len(scratches) != 2 {
+		t.Fatalf("expected one scratch document per depth, got %d", len(scratches))
+	}
+	if _, err := engine.measureHeight(nested); err != nil {
+		t.Fatalf("measureHeight returned error: %v", err)
+	}
+	if !slices.Equal(engine.scratches, scratches) {
+		t.Fatalf("expected later dry runs to reuse the scratch documents")
+	}
+}
+
+func TestPlanLineBreaksHonorsOrphansAndWidows(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	engine.pdf.SetY(engine.pageBreakTrigger() - 12)
+	heights := []float64{5, 5, 5, 5, 5, 5}
+
+	if breaks := engine.planLineBreaks(heights, 0, 0); breaks != nil {
+		t.Fatalf("expected no plan without settings, got %v", breaks)
+	}
+	if breaks := engine.planLineBreaks(heights, 3, 0); len(breaks) != 1 || breaks[0] != 0 {
+		t.Fatalf("expected the paragraph to move to the next page, got %v", breaks)
+	}
+	if breaks := engine.planLineBreaks(heights, 0, 5); len(breaks) != 1 || breaks[0] != 1 {
+		t.Fatalf("expected the break to move up to line 1, got %v", breaks)
+	}
+	if breaks := engine.planLineBreaks(heights, 2, 2); len(breaks) != 1 || breaks[0] != 2 {
+		t.Fatalf("expected the natural break at line 2, got %v", breaks)
+	}
+}
+
+func keepTestParagraph(engine *Engine, lines int) string {
+	content := "Lorem ipsum dolor sit amet"
+	engine.applyStyle("body")
+	for len(engine.pdf.SplitText(content, 50)) < lines {
+		content += " lorem ipsum dolor sit amet"
+	}
+	return content
+}
+
+func TestMinLinesBeforeMovesWrappedParagraph(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	content := keepTestParagraph(engine, 4)
+	lines := len(engine.pdf.SplitText(content, 50))
+	engine.pdf.SetY(engine.pageBreakTrigger() - 12)
+
+	err := engine.renderElement(models.SectionElement{Type: "text", Text: &models.Text{
+		Style: "body", Content: content, Width: 50, MinLinesBefore: 3, TocEntry: "true",
+	}})
+	if err != nil {
+		t.Fatalf("renderElement returned error: %v", err)
+	}
+
+	_, top, _, _ := engine.pdf.GetMargins()
+	if engine.pdf.PageNo() != 2 || math.Abs(engine.pdf.GetY()-(top+float64(lines)*5)) > 0.001 {
+		t.Fatalf("expected the whole paragraph on page 2, ended on page %d at %v", engine.pdf.PageNo(), engine.pdf.GetY())
+	}
+	if len(engine.tocCollected) != 1 || engine.tocCollected[0].page != 2 {
+		t.Fatalf("expected the paragraph to start on page 2, got %#v", engine.tocCollected)
+	}
+}
+
+func TestStyleMinLinesAfterKeepsLastLinesTogether(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	engine.styles["body"].MinLinesAfter = 3
+	content := keepTestParagraph(engine, 4)
+	lines := len(engine.pdf.SplitText(content, 50))
+	engine.pdf.SetY(engine.pageBreakTrigger() - 12)
+
+	err := engine.renderElement(models.SectionElement{Type: "text", Text: &models.Text{Style: "body", Content: content, Width: 50}})
+	if err != nil {
+		t.Fatalf("renderElement returned error: %v", err)
+	}
+
+	_, top, _, _ := engine.pdf.GetMargins()
+	if engine.pdf.PageNo() != 2 || math.Abs(engine.pdf.GetY()-(top+float64(lines-1)*5)) > 0.001 {
+		t.Fatalf("expected %d lines on page 2, ended on page %d at %v", lines-1, engine.pdf.PageNo(), engine.pdf.GetY())
+	}
+	if _, bottom := engine.pdf.GetAutoPageBreak(); bottom != 10 {
+		t.Fatalf("expected the bottom margin to be restored, got %v", bottom)
+	}
+}
+
+func TestMinLinesAfterBreaksRichText(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	content := keepTestParagraph(engine, 4)
+	lines := len(engine.pdf.SplitText(content, 50))
+	engine.pdf.SetY(engine.pageBreakTrigger() - 12)
+
+	err := engine.renderElement(models.SectionElement{Type: "text", Text: &models.Text{
+		Style: "body", Width: 50, MinLinesAfter: 3,
+		Runs: []models.TextRun{{Content: content[:11], Bold: true}, {Content: content[11:]}},
+	}})
+	if err != nil {
+		t.Fatalf("renderElement returned error: %v", err)
+	}
+
+	if engine.pdf.PageNo() != 2 {
+		t.Fatalf("expected the paragraph to continue on page 2, got page %d", engine.pdf.PageNo())
+	}
+	_, top, _, _ := engine.pdf.GetMargins()
+	if got := math.Round((engine.pdf.GetY() - top) / 5); got < 3 || int(got) >= lines {
+		t.Fatalf("expected at least 3 lines and fewer than %d on page 2, got %v", lines, got)
+	}
+}
@@ -0,0 +1,97 @@
+// Package engine provides link and anchor functionality.
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defineAnchor points the named anchor at the current position. The first
+// definition of a name wins.
+func (e *Engine) defineAnchor(name string) {
+	e.defineAnchorAt(name, e.pdf.PageNo(), e.pdf.GetY())
+}
+
+// defineAnchorAt points the named anchor at y on page, for elements that
+// know where they started only after drawing.
+func (e *Engine) defineAnchorAt(name string, page int, y float64) {
+	name = strings.TrimSpace(e.processTemplate(name))
+	if _, ok := e.anchorPages[name]; name == "" || ok {
+		return
+	}
+	e.pdf.SetLink(e.anchorLink(name), y, page)
+	e.anchorPages[name] = page
+}
+
+// anchorLink returns the link ID of the named anchor, registering it on first
+// use so links can refer to anchors defined later in the document.
+func (e *Engine) anchorLink(name string) int {
+	if link, ok := e.anchors[name]; ok {
+		return link
+	}
+	link := e.pdf.AddLink()
+	e.anchors[name] = link
+	return link
+}
+
+// resolveHref converts an href into a gofpdf internal link ID for "#name"
+// anchors or an external URL for anything else.
+func (e *Engine) resolveHref(href string) (link int, url string) {
+	href = strings.TrimSpace(e.processTemplate(href))
+	if name, ok := strings.CutPrefix(href, "#"); ok {
+		if name == "" {
+			return 0, ""
+		}
+		return e.anchorLink(name), ""
+	}
+	return 0, href
+}
+
+// addLink makes the given area of the current page a link to href.
+func (e *Engine) addLink(href string, x, y, w, h float64) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	link, url := e.resolveHref(href)
+	if link != 0 {
+		e.pdf.Link(x, y, w, h, link)
+	} else if url != "" {
+		e.pdf.LinkString(x, y, w, h, url)
+	}
+}
+
+// pageRef is the pageRef template function. It returns the page number of
+// the named anchor as of the previous layout pass, or "?" in the first.
+func (e *Engine) pageRef(name string) string {
+	name = strings.TrimSpace(name)
+	if e.pageRefs != nil {
+		e.pageRefs[name] = true
+	}
+	if page, ok := e.knownAnchorPages[name]; ok {
+		return strconv.Itoa(page)
+	}
+	return "?"
+}
+
+// checkAnchors reports links and page references to anchors that no element
+// defines.
+func (e *Engine) checkAnchors() error {
+	var undefined []string
+	for name := range e.anchors {
+		if _, ok := e.anchorPages[name]; !ok {
+			undefined = append(undefined, name)
+		}
+	}
+	for name := range e.pageRefs {
+		if _, ok := e.anchorPages[name]; !ok && e.anchors[name] == 0 {
+			undefined = append(undefined, name)
+		}
+	}
+	if len(undefined) == 0 {
+		return nil
+	}
+	sort.Strings(undefined)
+	return fmt.Errorf("undefined link anchor %q", undefined[0])
+}
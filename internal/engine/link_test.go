@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+func TestRenderSectionsLinksToAnchors(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Site": "https://example.com"})
+	engine.report.Sections.Sections = []models.Section{
+		{
+			Name: "summary",
+			Elements: []models.SectionElement{
+				{Type: "text", Text: &models.Text{Content: "See Appendix", Href: "#appendix"}},
+				{Type: "text", Text: &models.Text{Content: "Website", Href: "{{.Site}}"}},
+			},
+		},
+		{
+			Name:            "appendix",
+			Anchor:          "appendix",
+			PageBreakBefore: true,
+			Elements: []models.SectionElement{
+				{Type: "text", Text: &models.Text{Content: "Appendix"}},
+			},
+		},
+	}
+
+	if err := engine.renderSections(); err != nil {
+		t.Fatalf("renderSections returned error: %v", err)
+	}
+
+	output := renderedPDF(t, engine)
+	if !strings.Contains(output, "/URI (https://example.com)") {
+		t.Fatalf("expected external link annotation in output")
+	}
+	if !strings.Contains(output, "/Dest [") {
+		t.Fatalf("expected internal link annotation in output")
+	}
+	if engine.pdf.PageNo() != 2 {
+		t.Fatalf("expected appendix on page 2, got %d pages", engine.pdf.PageNo())
+	}
+}
+
+func TestRenderSectionsRejectsUndefinedAnchor(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	engine.report.Sections.Sections = []models.Section{{
+		Name: "summary",
+		Elements: []models.SectionElement{
+			{Type: "text", Text: &models.Text{Content: "Missing", Href: "#nowhere"}},
+		},
+	}}
+
+	err := engine.renderSections()
+	if err == nil || !strings.Contains(err.Error(), `undefined link anchor "nowhere"`) {
+		t.Fatalf("expected undefined anchor error, got %v", err)
+	}
+}
+
+func TestRenderElementsDefinesElementAnchors(t *testing.T) {
+	engine := newTestEngine(t, nil)
+
+	err := engine.renderElements([]models.SectionElement{
+		{Type: "text", Text: &models.Text{Content: "Jump", Href: "#total"}},
+		{Type: "spacer", Spacer: &models.Spacer{BaseElement: models.BaseElement{Anchor: "total"}, Height: 10}},
+	})
+	if err != nil {
+		t.Fatalf("renderElements returned error: %v", err)
+	}
+	if engine.anchorPages["total"] != 1 {
+		t.Fatalf("expected element anchor to be defined")
+	}
+	if err := engine.checkAnchors(); err != nil {
+		t.Fatalf("checkAnchors returned error: %v", err)
+	}
+}
+
+func TestRenderElementsAnchorsElementsOnTheirFirstPage(t *testing.T) {
+	rows := []interface{}{
+		map[string]interface{}{"Name": "Alpha"},
+		map[string]interface{}{"Name": "Beta"},
+	}
+	elements := []models.SectionElement{
+		{Type: "table", Table: &models.Table{
+			BaseElement: models.BaseElement{Anchor: "figures"},
+			DataSource:  "{{.Rows}}",
+			Columns:     models.Columns{Columns: []models.Column{{Header: "Name", Field: "Name"}}},
+		}},
+		{Type: "chart", Chart: &models.Chart{
+			BaseElement: models.BaseElement{Anchor: "trend"},
+			DataSource:  "{{.Rows}}",
+			Height:      40,
+		}},
+	}
+
+	for _, elem := range elements {
+		engine := newTestEngine(t, map[string]interface{}{"Rows": rows})
+		engine.pdf.SetY(engine.pageBreakTrigger() - 5)
+
+		if err := engine.renderElements([]models.SectionElement{elem}); err != nil {
+			t.Fatalf("renderElements returned error: %v", err)
+		}
+
+		anchor := elementBase(elem).Anchor
+		if got := engine.anchorPages[anchor]; got != 2 {
+			t.Fatalf("expected %s anchor on page 2 where the %s starts, got page %d", anchor, elem.Type, got)
+		}
+	}
+}
+
+func TestRenderResolvesPageRefs(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	engine.report.Sections.Sections = []models.Section{
+		{
+			Name: "summary",
+			Elements: []models.SectionElement{
+				{Type: "text", Text: &models.Text{Content: `See page {{pageRef "appendix"}}`}},
+			},
+		},
+		{
+			Name:            "appendix",
+			Anchor:          "appendix",
+			PageBreakBefore: true,
+			Elements: []models.SectionElement{
+				{Type: "text", Text: &models.Text{Content: "Appendix"}},
+			},
+		},
+	}
+
+	if err := engine.render(); err != nil {
+		t.Fatalf("render returned error: %v", err)
+	}
+
+	if got := engine.processTemplate(`{{pageRef "appendix"}}`); got != "2" {
+		t.Fatalf("expected pageRef to resolve to 2, got %q", got)
+	}
+}
+
+func TestRenderRejectsUndefinedPageRef(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	engine.report.Sections.Sections = []models.Section{{
+		Name: "summary",
+		Elements: []models.SectionElement{
+			{Type: "text", Text: &models.Text{Content: `See page {{pageRef "missing"}}`}},
+		},
+	}}
+
+	err := engine.render()
+	if err == nil || !strings.Contains(err.Error(), `undefined link anchor "missing"`) {
+		t.Fatalf("expected undefined anchor error, got %v", err)
+	}
+}
+
+func TestPageRefBeforeLayoutIsPlaceholder(t *testing.T) {
+	engine := newTestEngine(t, nil)
+
+	if got := engine.processTemplate(`page {{pageRef "later"}}`); got != "page ?" {
+		t.Fatalf("expected placeholder in the first pass, got %q", got)
+	}
+	if !engine.pageRefs["later"] {
+		t.Fatalf("expected pageRef to record the anchor name")
+	}
+}
@@ -151,7 +151,7 @@ func TestRenderHeaderFooterElementsOffsetsFooterY(t *testing.T) {
 		X:       15,
 		Y:       4,
 		Content: "{{.DocumentRef}}",
-	}}, nil, nil)
+	}}, nil, nil, nil)
 
 	if got := engine.pdf.GetY(); got <= footerTop {
 		t.Fatalf("expected footer text to render within footer area, got Y %.2f with footer top %.2f", got, footerTop)
@@ -1,6 +1,8 @@
 package engine
 
 import (
+	"fmt"
+	"math"
 	"strings"
 	"testing"
 
@@ -179,8 +181,8 @@ func TestRenderSectionRejectsUnsupportedRowChild(t *testing.T) {
 			Type: "row",
 			Row: &models.Row{
 				Elements: []models.SectionElement{{
-					Type: "list",
-					List: &models.List{Items: "{{.Items}}"},
+					Type:      "pageBreak",
+					PageBreak: &models.PageBreak{},
 				}},
 			},
 		}},
@@ -191,6 +193,104 @@ func TestRenderSectionRejectsUnsupportedRowChild(t *testing.T) {
 	}
 }
 
+func TestRenderRowSharesWidthWithFlowChildren(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Items": []interface{}{"alpha", "beta", "gamma"}})
+
+	row := models.Row{
+		Elements: []models.SectionElement{
+			{Type: "rectangle", Rectangle: &models.Rectangle{Width: 30, Height: 5}},
+			{Type: "list", List: &models.List{Items: "{{.Items}}", Style: "body"}},
+			{Type: "keyValueList", KVList: &models.KeyValueList{Style: "body", Items: []models.KeyValueItem{{Key: "Total", Value: "42"}}}},
+		},
+	}
+
+	startY := engine.pdf.GetY()
+	if err := engine.renderRow(&row); err != nil {
+		t.Fatalf("renderRow returned error: %v", err)
+	}
+
+	if got := engine.pdf.GetY(); got < startY+15 {
+		t.Fatalf("expected the row to advance by the list height, got %.2f", got)
+	}
+	if got := engine.pdf.GetX(); got != engine.flowLeftMargin() {
+		t.Fatalf("expected row to restore X to flow margin, got %.2f", got)
+	}
+
+	output := renderedPDF(t, engine)
+	// The list starts after the rectangle and the key-value list halfway
+	// through the remaining 160mm.
+	if x := textPosition(t, output, "gamma"); x < 40*72/25.4 || x > 120*72/25.4 {
+		t.Fatalf("expected the list beside the rectangle, got x %.2f", x)
+	}
+	if x := textPosition(t, output, "Total"); math.Abs(x-120*72/25.4) > 3 {
+		t.Fatalf("expected the key-value list at 120mm, got x %.2f", x)
+	}
+}
+
+func TestRenderRowKeepsFlowChildrenOnOnePage(t *testing.T) {
+	data := map[string]interface{}{"Items": []interface{}{"alpha", "beta", "gamma"}}
+	row := func(spacingAfter float64) *models.Row {
+		return &models.Row{Elements: []models.SectionElement{
+			{Type: "text", Text: &models.Text{Style: "body", Width: 30, Content: "Label"}},
+			{Type: "list", List: &models.List{BaseElement: models.BaseElement{SpacingAfter: spacingAfter}, Items: "{{.Items}}", Style: "body"}},
+		}}
+	}
+
+	reference := newTestEngine(t, data)
+	if err := reference.renderRow(row(0)); err != nil {
+		t.Fatalf("renderRow returned error: %v", err)
+	}
+
+	engine := newTestEngine(t, data)
+	engine.pdf.SetY(engine.pageBreakTrigger() - 8)
+	if err := engine.renderRow(row(10)); err != nil {
+		t.Fatalf("renderRow returned error: %v", err)
+	}
+
+	if engine.pdf.PageNo() != 2 {
+		t.Fatalf("expected the row to move to page 2, got page %d", engine.pdf.PageNo())
+	}
+	if got, want := engine.pdf.GetY(), reference.pdf.GetY(); math.Abs(got-want) > 0.001 {
+		t.Fatalf("expected the row to end with the list at %.2f, got %.2f", want, got)
+	}
+}
+
+func TestRenderRowLetsOverTallFlowChildBreak(t *testing.T) {
+	items := make([]interface{}, 100)
+	for idx := range items {
+		items[idx] = fmt.Sprintf("item %d", idx)
+	}
+	data := map[string]interface{}{"Items": items}
+	list := models.SectionElement{Type: "list", List: &models.List{Items: "{{.Items}}", Style: "body"}}
+
+	reference := newTestEngine(t, data)
+	if err := reference.renderElements([]models.SectionElement{list}); err != nil {
+		t.Fatalf("renderElements returned error: %v", err)
+	}
+
+	engine := newTestEngine(t, data)
+	err := engine.renderRow(&models.Row{Elements: []models.SectionElement{
+		list,
+		{Type: "text", Text: &models.Text{Style: "body", Width: 30, Content: "Label"}},
+	}})
+	if err != nil {
+		t.Fatalf("renderRow returned error: %v", err)
+	}
+
+	if got, want := engine.pdf.PageNo(), reference.pdf.PageNo(); got != want || got < 2 {
+		t.Fatalf("expected the row to end on the list's last page %d, got page %d", want, got)
+	}
+	if got, want := engine.pdf.GetY(), reference.pdf.GetY(); math.Abs(got-want) > 0.001 {
+		t.Fatalf("expected the row to end below the list at %.2f, got %.2f", want, got)
+	}
+	// Pages are written in order, so the label beside the start of the list
+	// comes before the items on later pages.
+	output := renderedPDF(t, engine)
+	if label, last := strings.Index(output, "(Label)"), strings.Index(output, "(item 99)"); label < 0 || label > last {
+		t.Fatalf("expected the label on the row's first page")
+	}
+}
+
 func TestRenderRowGridUsesTallestColumnHeight(t *testing.T) {
 	engine := newTestEngine(t, nil)
 	engine.applyStyle("body")
@@ -244,3 +344,73 @@ func TestRenderRowGridUsesTallestColumnHeight(t *testing.T) {
 		t.Fatalf("expected rowgrid column content to be rendered")
 	}
 }
+
+func TestRowGridColumnWidthsUseWeightsGapAndFixedWidths(t *testing.T) {
+	widths, err := rowGridColumnWidths(&models.RowGrid{Widths: "2,1,1", Gap: 5}, 190)
+	if err != nil {
+		t.Fatalf("rowGridColumnWidths returned error: %v", err)
+	}
+	if len(widths) != 3 || widths[0] != 90 || widths[1] != 45 || widths[2] != 45 {
+		t.Fatalf("expected widths 90, 45, 45, got %v", widths)
+	}
+
+	rowGrid := &models.RowGrid{
+		Widths: "2,1,1",
+		Cols:   []models.RowGridColumn{{Width: 40}, {Weight: 3}, {}},
+	}
+	widths, err = rowGridColumnWidths(rowGrid, 200)
+	if err != nil {
+		t.Fatalf("rowGridColumnWidths returned error: %v", err)
+	}
+	if widths[0] != 40 || widths[1] != 120 || widths[2] != 40 {
+		t.Fatalf("expected widths 40, 120, 40, got %v", widths)
+	}
+
+	if _, err := rowGridColumnWidths(&models.RowGrid{}, 190); err == nil {
+		t.Fatalf("expected a rowgrid without columns to return an error")
+	}
+}
+
+func TestRowGridColumnWidthsRejectOverflow(t *testing.T) {
+	for _, rowGrid := range []*models.RowGrid{
+		{Cols: []models.RowGridColumn{{Width: 250}, {}}},
+		{Cols: []models.RowGridColumn{{Width: 100}, {Width: 80}}},
+		{Columns: 3, Gap: 100},
+	} {
+		if widths, err := rowGridColumnWidths(rowGrid, 170); err == nil {
+			t.Fatalf("expected overflowing rowgrid %#v to return an error, got widths %v", rowGrid, widths)
+		}
+	}
+
+	if _, err := rowGridColumnWidths(&models.RowGrid{Cols: []models.RowGridColumn{{Width: 100}, {Width: 70}}}, 170); err != nil {
+		t.Fatalf("expected fixed columns filling the width exactly to be accepted, got %v", err)
+	}
+}
+
+func TestRenderRowGridDrawsDividersInGaps(t *testing.T) {
+	engine := newTestEngine(t, nil)
+
+	rowGrid := models.RowGrid{
+		Widths:  "1,1",
+		Gap:     10,
+		Divider: true,
+		Cols: []models.RowGridColumn{
+			{Elements: []models.SectionElement{{Type: "text", Text: &models.Text{Style: "body", Content: "left"}}}},
+			{Elements: []models.SectionElement{{Type: "text", Text: &models.Text{Style: "body", Content: "right"}}}},
+		},
+	}
+
+	if err := engine.renderRowGrid(&rowGrid); err != nil {
+		t.Fatalf("renderRowGrid returned error: %v", err)
+	}
+
+	output := renderedPDF(t, engine)
+	// The 190mm flow width leaves two 90mm columns around a 10mm gap.
+	if x := textPosition(t, output, "right"); math.Abs(x-(10+90+10)*72/25.4) > 3 {
+		t.Fatalf("expected the second column after the gap, got x %.2f", x)
+	}
+	divider := fmt.Sprintf("%.2f", (10+90+5)*72/25.4)
+	if !strings.Contains(output, divider+" ") || !strings.Contains(output, " l S") {
+		t.Fatalf("expected a divider at %s in the gap", divider)
+	}
+}
@@ -34,6 +34,9 @@ func TestDefaultFuncMapProvidesFormattingHelpers(t *testing.T) {
 	if got := engine.processTemplate("{{formatCurrency .Amount \"EUR \"}}"); got != "EUR 12.50" {
 		t.Fatalf("expected formatted currency, got %q", got)
 	}
+	if got := engine.processTemplate("{{formatCurrency -4.25}}"); got != "-$4.25" {
+		t.Fatalf("expected the minus sign before the currency symbol, got %q", got)
+	}
 	if got := engine.processTemplate("{{formatPercent .Rate 1}}"); got != "12.5%" {
 		t.Fatalf("expected formatted percent, got %q", got)
 	}
@@ -52,6 +55,25 @@ func TestDefaultFuncMapProvidesFormattingHelpers(t *testing.T) {
 	}
 }
 
+func TestArithmeticHelpersRejectNonNumericArguments(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{
+		"row": map[string]interface{}{"Qty": 3, "Price": "2.5"},
+	})
+
+	if got := engine.processTemplate("{{mul .row.Qty .row.Price}}"); got != "7.5" {
+		t.Fatalf("expected numeric arguments to multiply, got %q", got)
+	}
+	for _, tmpl := range []string{
+		"{{mul .row.Qty .row.Typo}}",
+		"{{add .row.Qty \"three\"}}",
+		"{{div .row.Typo 2}}",
+	} {
+		if got := engine.processTemplate(tmpl); got != tmpl {
+			t.Fatalf("expected %s to fail instead of rendering %q", tmpl, got)
+		}
+	}
+}
+
 func TestEmbeddedFontsAreRegistered(t *testing.T) {
 	fontData := mustReadFontFile(t)
 	engine := newTestEngine(t, nil)
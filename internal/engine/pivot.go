@@ -0,0 +1,156 @@
+// Package engine provides pivot table rendering functionality.
+package engine
+
+import (
+	"fmt"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+// pivotAxis collects the distinct values of one pivot field in order of
+// first appearance, together with the items that carry each value.
+type pivotAxis struct {
+	keys  []interface{}
+	items [][]interface{}
+	index map[string]int
+}
+
+func (axis *pivotAxis) add(key interface{}, item interface{}) int {
+	if axis.index == nil {
+		axis.index = make(map[string]int)
+	}
+
+	id := fmt.Sprint(key)
+	idx, ok := axis.index[id]
+	if !ok {
+		idx = len(axis.keys)
+		axis.index[id] = idx
+		axis.keys = append(axis.keys, key)
+		axis.items = append(axis.items, nil)
+	}
+	axis.items[idx] = append(axis.items[idx], item)
+	return idx
+}
+
+// renderPivot renders a crosstab of the pivot data source. The matrix is
+// laid out as a regular table, so it shares column sizing, header repeats and
+// page breaks with renderTable. Totals aggregate the underlying items rather
+// than the cell values, so averages stay correct.
+func (e *Engine) renderPivot(pivot *models.Pivot) {
+	items := e.applyDataQuery(e.resolveDataItems(pivot.DataSource), pivot.DataQuery, "item")
+
+	aggregate := pivot.Aggregate
+	if aggregate == "" {
+		aggregate = "sum"
+	}
+
+	var rowAxis, columnAxis pivotAxis
+	cells := make(map[[2]int][]interface{})
+	for _, item := range items {
+		rowKey, _ := resolveDataPath(item, pivot.RowField)
+		columnKey, _ := resolveDataPath(item, pivot.ColumnField)
+		cell := [2]int{rowAxis.add(rowKey, item), columnAxis.add(columnKey, item)}
+		cells[cell] = append(cells[cell], item)
+	}
+
+	rowTotals := boolValue(pivot.RowTotals, true)
+	totalLabel := pivot.TotalLabel
+	if totalLabel == "" {
+		totalLabel = "Total"
+	}
+
+	table := pivotTable(pivot, columnAxis.keys, rowTotals, totalLabel)
+
+	rows := make([]interface{}, len(rowAxis.keys))
+	for rowIdx, rowKey := range rowAxis.keys {
+		row := map[string]interface{}{"label": formatValue(rowKey, "")}
+		for columnIdx := range columnAxis.keys {
+			// Cells without items stay blank for every aggregate, rather
+			// than showing a count of zero.
+			cellItems := cells[[2]int{rowIdx, columnIdx}]
+			if len(cellItems) == 0 {
+				continue
+			}
+			if value, ok := aggregateTableRows(cellItems, pivot.ValueField, aggregate); ok {
+				row[pivotColumnField(columnIdx)] = value
+			}
+		}
+		if rowTotals {
+			if value, ok := aggregateTableRows(rowAxis.items[rowIdx], pivot.ValueField, aggregate); ok {
+				row["total"] = value
+			}
+		}
+		rows[rowIdx] = row
+	}
+
+	if boolValue(pivot.ColumnTotals, true) && len(rows) > 0 {
+		footerCells := []models.TableFooterCell{{Label: totalLabel}}
+		for _, columnItems := range columnAxis.items {
+			footerCells = append(footerCells, pivotTotalCell(pivot, columnItems, aggregate))
+		}
+		if rowTotals {
+			footerCells = append(footerCells, pivotTotalCell(pivot, items, aggregate))
+		}
+		table.Footer = &models.TableFooter{Style: pivot.TotalStyle, Cells: footerCells}
+	}
+
+	e.renderTableRows(table, rows)
+}
+
+// pivotTable builds the table definition for a pivot: the row value column,
+// one column per distinct column value and an optional total column.
+func pivotTable(pivot *models.Pivot, columnKeys []interface{}, rowTotals bool, totalLabel string) *models.Table {
+	rowHeader := pivot.RowHeader
+	if rowHeader == "" {
+		rowHeader = pivot.RowField
+	}
+	rowWidth := pivot.RowWidth
+	if rowWidth == "" {
+		rowWidth = "auto"
+	}
+
+	columns := []models.Column{{Header: rowHeader, Field: "label", Width: rowWidth}}
+	for idx, key := range columnKeys {
+		columns = append(columns, models.Column{
+			Header: formatValue(key, ""),
+			Field:  pivotColumnField(idx),
+			Width:  pivot.ColumnWidth,
+			Align:  "R",
+			Format: pivot.Format,
+		})
+	}
+	if rowTotals {
+		columns = append(columns, models.Column{
+			Header: totalLabel,
+			Field:  "total",
+			Width:  pivot.ColumnWidth,
+			Align:  "R",
+			Format: pivot.Format,
+		})
+	}
+
+	return &models.Table{
+		BaseElement:       pivot.BaseElement,
+		HeaderStyle:       pivot.HeaderStyle,
+		CellStyle:         pivot.CellStyle,
+		Border:            pivot.Border,
+		RepeatHeader:      pivot.RepeatHeader,
+		AlternateRowColor: pivot.AlternateRowColor,
+		Columns:           models.Columns{Columns: columns},
+	}
+}
+
+// pivotTotalCell returns a footer cell showing the aggregate of items. The
+// value is computed here because the footer would otherwise aggregate the
+// already aggregated cells.
+func pivotTotalCell(pivot *models.Pivot, items []interface{}, aggregate string) models.TableFooterCell {
+	cell := models.TableFooterCell{Align: "R"}
+	if value, ok := aggregateTableRows(items, pivot.ValueField, aggregate); ok {
+		cell.Label = formatValue(value, pivot.Format)
+	}
+	return cell
+}
+
+func pivotColumnField(index int) string {
+	return fmt.Sprintf("c%d", index)
+}
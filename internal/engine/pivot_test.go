@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+func pivotTestSales() []interface{} {
+	return []interface{}{
+		map[string]interface{}{"region": "North", "month": "Jan", "amount": 100.0},
+		map[string]interface{}{"region": "North", "month": "Feb", "amount": 50.0},
+		map[string]interface{}{"region": "South", "month": "Jan", "amount": 30.0},
+		map[string]interface{}{"region": "North", "month": "Jan", "amount": 20.0},
+		map[string]interface{}{"region": "South", "month": "Mar", "amount": 7.0},
+	}
+}
+
+func TestRenderPivotBuildsMatrixWithTotals(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Sales": pivotTestSales()})
+
+	engine.renderPivot(&models.Pivot{
+		DataSource:  "{{.Sales}}",
+		RowField:    "region",
+		ColumnField: "month",
+		ValueField:  "amount",
+		RowHeader:   "Region",
+		Border:      true,
+	})
+
+	output := renderedPDF(t, engine)
+	for _, want := range []string{
+		"(Region)", "(Jan)", "(Feb)", "(Mar)", "(Total)",
+		"(North)", "(120)", "(50)", "(170)",
+		"(South)", "(30)", "(7)", "(37)",
+		"(150)", "(207)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %s in pivot output", want)
+		}
+	}
+	if strings.Index(output, "(Jan)") > strings.Index(output, "(Feb)") || strings.Index(output, "(North)") > strings.Index(output, "(South)") {
+		t.Fatalf("expected pivot axes in order of first appearance")
+	}
+}
+
+func TestRenderPivotAveragesTotalsFromItems(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Sales": pivotTestSales()})
+	columnTotals := false
+
+	engine.renderPivot(&models.Pivot{
+		DataSource:   "{{.Sales}}",
+		RowField:     "region",
+		ColumnField:  "month",
+		ValueField:   "amount",
+		Aggregate:    "avg",
+		TotalLabel:   "Average",
+		ColumnTotals: &columnTotals,
+	})
+
+	output := renderedPDF(t, engine)
+	// North averages 100, 50 and 20 rather than the cell averages 60 and 50.
+	if !strings.Contains(output, "(56.67)") {
+		t.Fatalf("expected North row average over its items")
+	}
+	if strings.Count(output, "(Average)") != 1 {
+		t.Fatalf("expected only the total column header when column totals are disabled")
+	}
+}
+
+func TestRenderPivotLeavesEmptyCountCellsBlank(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Sales": pivotTestSales()})
+
+	engine.renderPivot(&models.Pivot{
+		DataSource:  "{{.Sales}}",
+		RowField:    "region",
+		ColumnField: "month",
+		ValueField:  "amount",
+		Aggregate:   "count",
+	})
+
+	output := renderedPDF(t, engine)
+	for _, want := range []string{"(2)", "(1)", "(3)", "(5)"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %s in pivot output", want)
+		}
+	}
+	// North has no March sales and South none in February.
+	if strings.Contains(output, "(0)") {
+		t.Fatalf("expected cells without items to be blank rather than a count of zero")
+	}
+}
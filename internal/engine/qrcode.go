@@ -0,0 +1,117 @@
+// Package engine provides QR code rendering functionality.
+package engine
+
+import (
+	"fmt"
+
+	"github.com/dannyswat/reportgo/internal/models"
+	"github.com/dannyswat/reportgo/internal/qrcode"
+)
+
+const (
+	// defaultQRCodeSize is the side length of a QR code symbol, including
+	// its quiet zone, when none is set.
+	defaultQRCodeSize = 25.0
+
+	// defaultQRCodeQuietZone is the width of the light margin around a QR
+	// code, in modules, when none is set.
+	defaultQRCodeQuietZone = 4
+)
+
+var (
+	// defaultSymbolColor is the color of QR code modules and barcode bars.
+	defaultSymbolColor = models.RGBColor{R: 0, G: 0, B: 0}
+
+	// quietZoneColor fills the quiet zones of QR codes and barcodes, so
+	// they scan on colored backgrounds too.
+	quietZoneColor = models.RGBColor{R: 255, G: 255, B: 255}
+)
+
+// renderQRCode encodes the element value and draws the symbol. It is
+// positioned like an image and advances Y past the symbol.
+func (e *Engine) renderQRCode(qr *models.QRCode) error {
+	level, err := qrcode.ParseLevel(qr.ErrorCorrection)
+	if err != nil {
+		return err
+	}
+
+	value := e.processTemplate(qr.Value)
+	code, err := qrcode.Encode(value, level)
+	if err != nil {
+		return fmt.Errorf("qrcode %q: %w", value, err)
+	}
+
+	size := qrCodeSize(qr)
+
+	x := qr.X
+	y := qr.Y
+	if x == 0 {
+		x, _ = e.pdf.GetXY()
+	}
+
+	if qr.Align == "C" {
+		x = e.flowLeftMargin() + (e.flowContentWidth()-size)/2
+	} else if qr.Align == "R" {
+		pageWidth, _ := e.pdf.GetPageSize()
+		x = pageWidth - e.flowRightMargin() - size
+	} else if qr.X == 0 {
+		x = e.flowLeftMargin()
+	}
+
+	if y == 0 {
+		y = e.pdf.GetY()
+	} else {
+		_, pageHeight := e.pdf.GetPageSize()
+		y = e.resolvePositionedY(y, e.pdf.GetY(), pageHeight)
+	}
+
+	e.drawQRCode(code, x, y, size, intValue(qr.QuietZone, defaultQRCodeQuietZone), qr.Color)
+
+	e.pdf.SetY(y + size)
+	if qr.SpacingAfter > 0 {
+		e.pdf.Ln(qr.SpacingAfter)
+	}
+
+	return nil
+}
+
+// qrCodeSize returns the size of a QR code element, quiet zone included.
+func qrCodeSize(qr *models.QRCode) float64 {
+	if qr.Size <= 0 {
+		return defaultQRCodeSize
+	}
+	return qr.Size
+}
+
+// drawQRCode fills the quiet zone of quietZone modules around the symbol and
+// the dark modules of code, merging horizontal runs into a single rectangle
+// each.
+func (e *Engine) drawQRCode(code *qrcode.Code, x, y, size float64, quietZone int, color string) {
+	quietZone = max(quietZone, 0)
+	module := size / float64(code.Size()+2*quietZone)
+
+	e.withGraphicsState(func() {
+		if quietZone > 0 {
+			e.pdf.SetFillColor(quietZoneColor.ToRGB())
+			e.pdf.Rect(x, y, size, size, "F")
+			x += float64(quietZone) * module
+			y += float64(quietZone) * module
+		}
+
+		e.pdf.SetFillColor(graphicColor(color, defaultSymbolColor))
+		for row := 0; row < code.Size(); row++ {
+			for col := 0; col < code.Size(); {
+				if !code.Dark(col, row) {
+					col++
+					continue
+				}
+
+				start := col
+				for col < code.Size() && code.Dark(col, row) {
+					col++
+				}
+				e.pdf.Rect(x+float64(start)*module, y+float64(row)*module, float64(col-start)*module, module, "F")
+			}
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+	"github.com/dannyswat/reportgo/internal/qrcode"
+)
+
+func TestRenderQRCodeAdvancesBySize(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"InvoiceURL": "https://example.com/invoice/42"})
+
+	startY := engine.pdf.GetY()
+	err := engine.renderQRCode(&models.QRCode{
+		Value: "{{.InvoiceURL}}",
+		Size:  30,
+		Align: "C",
+		Color: "#FF0000",
+	})
+	if err != nil {
+		t.Fatalf("renderQRCode returned error: %v", err)
+	}
+
+	if got, want := engine.pdf.GetY(), startY+30; got != want {
+		t.Fatalf("expected Y %.2f after the symbol, got %.2f", want, got)
+	}
+	if output := renderedPDF(t, engine); !strings.Contains(output, "1.000 0.000 0.000 rg") {
+		t.Fatalf("expected QR code fill color in output")
+	}
+}
+
+func TestRenderQRCodeReservesQuietZone(t *testing.T) {
+	// "A" encodes as a 21 module symbol, so a 29mm code with the default
+	// quiet zone has 1mm modules and starts its dark modules 4mm in.
+	engine := newTestEngine(t, nil)
+	if err := engine.renderQRCode(&models.QRCode{Value: "A", Size: 29}); err != nil {
+		t.Fatalf("renderQRCode returned error: %v", err)
+	}
+	output := renderedPDF(t, engine)
+	if !strings.Contains(output, "1.000 g\n28.35 813.54 82.20 -82.20 re f") {
+		t.Fatalf("expected the quiet zone to be filled white across the whole size")
+	}
+	if !strings.Contains(output, "0.000 g\n39.69 802.20 ") {
+		t.Fatalf("expected the first dark module 4 modules inside the quiet zone")
+	}
+
+	none := 0
+	engine = newTestEngine(t, nil)
+	if err := engine.renderQRCode(&models.QRCode{Value: "A", Size: 21, QuietZone: &none}); err != nil {
+		t.Fatalf("renderQRCode returned error: %v", err)
+	}
+	output = renderedPDF(t, engine)
+	if strings.Contains(output, "1.000 g") || !strings.Contains(output, "0.000 g\n28.35 813.54 ") {
+		t.Fatalf("expected quietZone=\"0\" to draw the symbol from the element origin")
+	}
+}
+
+func TestRenderQRCodeReportsEncodingErrors(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Payload": strings.Repeat("x", 3000)})
+
+	err := engine.renderElement(models.SectionElement{Type: "qrcode", QRCode: &models.QRCode{Value: "{{.Payload}}"}})
+	if !errors.Is(err, qrcode.ErrTooLong) {
+		t.Fatalf("expected ErrTooLong, got %v", err)
+	}
+
+	err = engine.renderQRCode(&models.QRCode{Value: "x", ErrorCorrection: "Z"})
+	if err == nil {
+		t.Fatal("expected error for unknown error correction level")
+	}
+}
+
+func TestRenderRowPlacesQRCode(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Ref": "INV-42"})
+
+	startY := engine.pdf.GetY()
+	err := engine.renderRow(&models.Row{Elements: []models.SectionElement{
+		{Type: "qrcode", QRCode: &models.QRCode{Value: "{{.Ref}}", Size: 20}},
+		{Type: "text", Text: &models.Text{Content: "Scan to pay"}},
+	}})
+	if err != nil {
+		t.Fatalf("renderRow returned error: %v", err)
+	}
+
+	if got, want := engine.pdf.GetY(), startY+20; got != want {
+		t.Fatalf("expected row to end below the QR code at %.2f, got %.2f", want, got)
+	}
+	if output := renderedPDF(t, engine); !strings.Contains(output, "(Scan to pay)") {
+		t.Fatalf("expected row text next to QR code")
+	}
+}
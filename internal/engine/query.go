@@ -0,0 +1,97 @@
+// Package engine provides data query functionality.
+package engine
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+// applyDataQuery filters, sorts and limits items, in that order. The filter
+// condition sees the current item under the given variable name. The source
+// slice is never reordered.
+func (e *Engine) applyDataQuery(items []interface{}, query models.DataQuery, variable string) []interface{} {
+	result := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if query.Filter == "" {
+			result = append(result, item)
+			continue
+		}
+
+		keep := false
+		_ = e.withScopedData(variable, item, func() error {
+			keep = e.shouldRenderCondition(query.Filter)
+			return nil
+		})
+		if keep {
+			result = append(result, item)
+		}
+	}
+
+	if query.SortBy != "" {
+		descending := strings.EqualFold(strings.TrimSpace(query.SortOrder), "desc")
+		sort.SliceStable(result, func(i, j int) bool {
+			left, _ := resolveDataPath(result[i], query.SortBy)
+			right, _ := resolveDataPath(result[j], query.SortBy)
+			if descending {
+				return compareDataValues(right, left) < 0
+			}
+			return compareDataValues(left, right) < 0
+		})
+	}
+
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+
+	return result
+}
+
+// Kinds of field values, in the order they sort in.
+const (
+	missingValue = iota
+	numberValue
+	timeValue
+	textValue
+)
+
+// compareDataValues orders two field values. Missing values sort first,
+// then numbers, times and anything else, so values of different kinds
+// always sort the same way. Within a kind, numbers compare numerically,
+// times chronologically and anything else by its formatted text.
+func compareDataValues(left, right interface{}) int {
+	leftKind, leftNumber := dataValueKind(left)
+	rightKind, rightNumber := dataValueKind(right)
+	if leftKind != rightKind {
+		return cmp.Compare(leftKind, rightKind)
+	}
+
+	switch leftKind {
+	case missingValue:
+		return 0
+	case numberValue:
+		return cmp.Compare(leftNumber, rightNumber)
+	case timeValue:
+		return left.(time.Time).Compare(right.(time.Time))
+	default:
+		return strings.Compare(formatValue(left, ""), formatValue(right, ""))
+	}
+}
+
+// dataValueKind returns the kind of a field value, and its number for a
+// numeric one. Strings holding a number count as numbers.
+func dataValueKind(value interface{}) (int, float64) {
+	if value == nil {
+		return missingValue, 0
+	}
+	if _, ok := value.(time.Time); ok {
+		return timeValue, 0
+	}
+	if number, ok := toFloat64(value); ok {
+		return numberValue, number
+	}
+	return textValue, 0
+}
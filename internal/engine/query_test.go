@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+func TestApplyDataQueryFiltersSortsAndLimits(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	items := []interface{}{
+		map[string]interface{}{"name": "Acme", "balance": 120.0, "paid": false},
+		map[string]interface{}{"name": "Globex", "balance": 75.5, "paid": true},
+		map[string]interface{}{"name": "Initech", "balance": 310.0, "paid": false},
+		map[string]interface{}{"name": "Umbrella", "balance": 42.0, "paid": false},
+	}
+
+	got := engine.applyDataQuery(items, models.DataQuery{
+		Filter:    "{{not .item.paid}}",
+		SortBy:    "balance",
+		SortOrder: "desc",
+		Limit:     2,
+	}, "item")
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 items after limit, got %d", len(got))
+	}
+	for idx, want := range []string{"Initech", "Acme"} {
+		if name := got[idx].(map[string]interface{})["name"]; name != want {
+			t.Fatalf("expected item %d to be %s, got %v", idx, want, name)
+		}
+	}
+	if first := items[0].(map[string]interface{})["name"]; first != "Acme" {
+		t.Fatalf("expected source items to keep their order, got %v first", first)
+	}
+}
+
+func TestCompareDataValues(t *testing.T) {
+	tests := []struct {
+		left, right interface{}
+		want        int
+	}{
+		{9, "10", -1},
+		{"apple", "banana", -1},
+		{nil, "anything", -1},
+		{2.5, 2.5, 0},
+		{"b", "a", 1},
+	}
+
+	for _, tt := range tests {
+		if got := compareDataValues(tt.left, tt.right); got != tt.want {
+			t.Fatalf("compareDataValues(%v, %v) = %d, want %d", tt.left, tt.right, got, tt.want)
+		}
+	}
+}
+
+func TestCompareDataValuesSortsMixedKinds(t *testing.T) {
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	values := []interface{}{"pear", day.AddDate(0, 0, 1), 10, nil, "apple", "2", day, 3.5}
+	sort.SliceStable(values, func(i, j int) bool {
+		return compareDataValues(values[i], values[j]) < 0
+	})
+
+	want := []interface{}{nil, "2", 3.5, 10, day, day.AddDate(0, 0, 1), "apple", "pear"}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("expected missing values, numbers, times then text, got %v", values)
+	}
+
+	// Every pair of kinds orders the same way both ways round.
+	for _, left := range want {
+		for _, right := range want {
+			if compareDataValues(left, right) != -compareDataValues(right, left) {
+				t.Fatalf("compareDataValues(%v, %v) is not antisymmetric", left, right)
+			}
+		}
+	}
+}
+
+func TestRenderTableAppliesDataQuery(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": tableTestRows(12)})
+	table := tableTestTable()
+	table.DataQuery = models.DataQuery{
+		Filter:    "{{if gt .row.amount 3.0}}true{{end}}",
+		SortBy:    "amount",
+		SortOrder: "desc",
+		Limit:     3,
+	}
+
+	engine.renderTable(table)
+
+	output := renderedPDF(t, engine)
+	for _, want := range []string{"(TX-012)", "(TX-011)", "(TX-010)"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %s among the top rows", want)
+		}
+	}
+	if strings.Contains(output, "(TX-009)") || strings.Contains(output, "(TX-001)") {
+		t.Fatalf("expected rows outside the limit to be skipped")
+	}
+	if strings.Index(output, "(TX-012)") > strings.Index(output, "(TX-010)") {
+		t.Fatalf("expected rows in descending amount order")
+	}
+}
+
+func TestRenderListAndSectionLoopApplyDataQuery(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{
+		"Tags": []string{"zeta", "alpha", "mu"},
+		"Invoices": []map[string]interface{}{
+			{"Number": "INV-1", "Status": "paid"},
+			{"Number": "INV-2", "Status": "open"},
+			{"Number": "INV-3", "Status": "open"},
+		},
+	})
+
+	engine.renderList(&models.List{Items: "{{.Tags}}", DataQuery: models.DataQuery{Filter: `{{ne .item "mu"}}`}})
+	section := models.Section{
+		Loop:         "{{.Invoices}}",
+		LoopVariable: "invoice",
+		DataQuery:    models.DataQuery{Filter: `{{eq .invoice.Status "open"}}`, SortBy: "Number", SortOrder: "desc"},
+		Elements: []models.SectionElement{
+			{Type: "text", Text: &models.Text{Style: "body", Content: "Invoice {{.invoice.Number}}"}},
+		},
+	}
+	if err := engine.renderSection(&section); err != nil {
+		t.Fatalf("renderSection returned error: %v", err)
+	}
+
+	output := renderedPDF(t, engine)
+	if !strings.Contains(output, "zeta") || strings.Contains(output, "(mu)") {
+		t.Fatalf("expected list filter to drop mu")
+	}
+	if strings.Contains(output, "INV-1") {
+		t.Fatalf("expected paid invoice to be filtered out of the loop")
+	}
+	if strings.Index(output, "INV-3") > strings.Index(output, "INV-2") {
+		t.Fatalf("expected loop items sorted by number descending")
+	}
+}
@@ -3,6 +3,11 @@ package engine
 
 import (
 	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/dannyswat/reportgo/internal/models"
 )
@@ -33,6 +38,10 @@ func (e *Engine) renderText(text *models.Text) {
 		e.pdf.SetX(e.flowLeftMargin())
 	}
 
+	startX, startY := e.pdf.GetXY()
+	startPage := e.pdf.PageNo()
+	linkWidth := 0.0
+
 	// Get alignment
 	align := e.resolveTextAlign(text)
 
@@ -42,9 +51,23 @@ func (e *Engine) renderText(text *models.Text) {
 		lineHeight = style.LineHeight
 	}
 
+	minLinesBefore, minLinesAfter := e.textMinLines(text)
+
 	// Render text
-	if text.Width > 0 {
-		e.pdf.MultiCell(text.Width, lineHeight, content, "", align, false)
+	if len(text.Runs) > 0 {
+		width := text.Width
+		if width <= 0 {
+			currentX, _ := e.pdf.GetXY()
+			width = e.flowAvailableWidthFrom(currentX)
+			if width <= 0 {
+				width = 1
+			}
+		}
+		startPage, startY = e.renderRichText(text.Runs, width, text.Width > 0 || text.Wrap, align, lineHeight, minLinesBefore, minLinesAfter)
+		linkWidth = width
+	} else if text.Width > 0 {
+		startPage, startY = e.multiCellWithBreaks(text.Width, lineHeight, content, align, minLinesBefore, minLinesAfter)
+		linkWidth = text.Width
 	} else if text.Wrap {
 		// Wrap against the effective current X position so indented content
 		// stays within the right content boundary.
@@ -53,7 +76,8 @@ func (e *Engine) renderText(text *models.Text) {
 		if availableWidth <= 0 {
 			availableWidth = 1
 		}
-		e.pdf.MultiCell(availableWidth, lineHeight, content, "", align, false)
+		startPage, startY = e.multiCellWithBreaks(availableWidth, lineHeight, content, align, minLinesBefore, minLinesAfter)
+		linkWidth = availableWidth
 	} else {
 		currentX, _ := e.pdf.GetXY()
 		availableWidth := e.flowAvailableWidthFrom(currentX)
@@ -61,6 +85,43 @@ func (e *Engine) renderText(text *models.Text) {
 			availableWidth = 1
 		}
 		e.pdf.CellFormat(availableWidth, lineHeight, content, "", 1, align, false, 0, "")
+
+		// Only the text itself is clickable, not the rest of the line. The
+		// cell may have moved to a new page, so measure up from its bottom.
+		linkWidth = e.pdf.GetStringWidth(content) + 2*e.pdf.GetCellMargin()
+		if linkWidth > availableWidth {
+			linkWidth = availableWidth
+		}
+		switch align {
+		case "C":
+			startX += (availableWidth - linkWidth) / 2
+		case "R":
+			startX += availableWidth - linkWidth
+		}
+		startY = e.pdf.GetY() - lineHeight
+		startPage = e.pdf.PageNo()
+	}
+
+	title, isEntry := e.tocTitle(text.TocEntry, text.Content)
+	if isEntry {
+		e.addTocEntry(title, text.TocLevel, startPage, startY)
+	}
+	e.defineAnchorAt(text.Anchor, startPage, startY)
+
+	if e.pdf.PageNo() != startPage {
+		// Text that flowed onto a new page is bookmarked and linked from
+		// its part there.
+		_, startY, _, _ = e.pdf.GetMargins()
+	}
+	if isEntry {
+		level := text.TocLevel
+		if level < 1 {
+			level = 1
+		}
+		e.addBookmark(title, e.headingBookmarkLevel+level-1, startY)
+	}
+	if text.Href != "" {
+		e.addLink(text.Href, startX, startY, linkWidth, e.pdf.GetY()-startY)
 	}
 
 	// Add spacing after
@@ -115,7 +176,11 @@ func (e *Engine) renderImage(img *models.Image) {
 		y = e.resolvePositionedY(y, e.pdf.GetY(), pageHeight)
 	}
 
-	e.pdf.Image(path, x, y, img.Width, img.Height, false, "", 0, "")
+	link, url := 0, ""
+	if img.Href != "" {
+		link, url = e.resolveHref(img.Href)
+	}
+	e.pdf.Image(path, x, y, img.Width, img.Height, false, "", link, url)
 
 	// Move Y position
 	if img.Height > 0 {
@@ -127,105 +192,18 @@ func (e *Engine) renderImage(img *models.Image) {
 	}
 }
 
-// renderTable renders a table element.
-func (e *Engine) renderTable(table *models.Table) {
-	e.pdf.SetX(e.flowLeftMargin())
-
-	// Apply header style
-	if table.HeaderStyle != "" {
-		e.applyStyle(table.HeaderStyle)
-	}
-
-	// Render header row
-	for _, col := range table.Columns.Columns {
-		fill := table.HeaderStyle != ""
-		e.pdf.CellFormat(col.Width, 7, col.Header, "1", 0, "C", fill, 0, "")
-	}
-	e.pdf.Ln(-1)
-
-	// Get data from template
-	dataKey := extractDataKey(table.DataSource)
-	data, ok := e.data[dataKey]
-	if !ok {
-		return
-	}
-
-	// Convert data to slice of maps - handle both []interface{} and []map[string]interface{}
-	var rows []map[string]interface{}
-	switch v := data.(type) {
-	case []interface{}:
-		for _, item := range v {
-			if m, ok := item.(map[string]interface{}); ok {
-				rows = append(rows, m)
-			}
-		}
-	case []map[string]interface{}:
-		rows = v
-	default:
-		return
-	}
-
-	// Render data rows
-	for i, rowMap := range rows {
-		if table.CellStyle != "" {
-			e.applyStyle(table.CellStyle)
-		}
-
-		e.pdf.SetX(e.flowLeftMargin())
-
-		// Alternate row colors
-		fill := false
-		if table.AlternateRowColor != nil && i%2 == 0 {
-			r, g, b := table.AlternateRowColor.ToRGB()
-			e.pdf.SetFillColor(r, g, b)
-			fill = true
-		}
-
-		for _, col := range table.Columns.Columns {
-			value := formatValue(rowMap[col.Field], col.Format)
-			align := col.Align
-			if align == "" {
-				align = "L"
-			}
-			border := ""
-			if table.Border {
-				border = "1"
-			}
-			e.pdf.CellFormat(col.Width, 7, value, border, 0, align, fill, 0, "")
-		}
-		e.pdf.Ln(-1)
-	}
-
-	if table.SpacingAfter > 0 {
-		e.pdf.Ln(table.SpacingAfter)
-	}
-}
-
 // renderList renders a list element.
 func (e *Engine) renderList(list *models.List) {
 	if list.Style != "" {
 		e.applyStyle(list.Style)
 	}
 
-	dataKey := extractDataKey(list.Items)
-	data, ok := e.data[dataKey]
-	if !ok {
-		return
-	}
-
-	// Convert data to slice of strings - handle both []interface{} and []string
 	var items []string
-	switch v := data.(type) {
-	case []interface{}:
-		for _, item := range v {
-			if s, ok := item.(string); ok {
-				items = append(items, s)
-			}
+	for _, item := range e.applyDataQuery(e.resolveDataItems(list.Items), list.DataQuery, "item") {
+		if item == nil {
+			continue
 		}
-	case []string:
-		items = v
-	default:
-		return
+		items = append(items, formatValue(item, ""))
 	}
 
 	bullet := list.Bullet
@@ -324,12 +302,38 @@ func (e *Engine) renderRow(row *models.Row) error {
 		}
 	}
 
+	// Flow children can break across pages, so a row with them starts a new
+	// page when it does not fit on this one but would fit on an empty one.
+	if slices.ContainsFunc(visible, isRowFlowChild) {
+		if err := e.keepTogether(func() error {
+			flat := *row
+			flat.SpacingAfter = 0
+			return e.renderRow(&flat)
+		}); err != nil {
+			return err
+		}
+	}
+
 	baseX := e.flowLeftMargin()
 	baseY := e.pdf.GetY()
 	currentX := baseX
-	maxY := baseY
+
+	// Every child starts on the row's first page, and the row ends below
+	// the child that reaches furthest, on the last page any child reaches.
+	startPage := e.pdf.PageNo()
+	endPage, maxY := startPage, baseY
+	settle := func(childEndY float64) {
+		if page := e.pdf.PageNo(); page > endPage {
+			endPage, maxY = page, childEndY
+		} else if page == endPage && childEndY > maxY {
+			maxY = childEndY
+		}
+	}
 
 	for idx, elem := range visible {
+		e.pdf.SetPage(startPage)
+		e.pdf.SetXY(currentX, baseY)
+
 		switch elem.Type {
 		case "text":
 			if elem.Text == nil {
@@ -348,9 +352,7 @@ func (e *Engine) renderRow(row *models.Row) error {
 			e.renderText(&text)
 
 			_, childEndY := e.pdf.GetXY()
-			if childEndY > maxY {
-				maxY = childEndY
-			}
+			settle(childEndY)
 			childEndX := text.X + text.Width
 			if childEndX > currentX {
 				currentX = childEndX
@@ -372,19 +374,136 @@ func (e *Engine) renderRow(row *models.Row) error {
 			e.renderImage(&img)
 
 			childEndY := img.Y + img.Height
-			if childEndY > maxY {
-				maxY = childEndY
-			}
+			settle(childEndY)
 			childEndX := img.X + img.Width
 			if childEndX > currentX {
 				currentX = childEndX
 			}
 			e.pdf.SetXY(currentX, baseY)
-		default:
+		case "qrcode":
+			if elem.QRCode == nil {
+				continue
+			}
+
+			qr := *elem.QRCode
+			qr.X = currentX + qr.X
+			qr.Y = baseY + qr.Y
+			qr.Align = ""
+			qr.SpacingAfter = 0
+			if err := e.renderQRCode(&qr); err != nil {
+				return err
+			}
+
+			size := qrCodeSize(&qr)
+			childEndY := qr.Y + size
+			settle(childEndY)
+			childEndX := qr.X + size
+			if childEndX > currentX {
+				currentX = childEndX
+			}
+			e.pdf.SetXY(currentX, baseY)
+		case "barcode":
+			if elem.Barcode == nil {
+				continue
+			}
+
+			bc := *elem.Barcode
+			bc.X = currentX + bc.X
+			bc.Y = baseY + bc.Y
+			bc.Align = ""
+			bc.SpacingAfter = 0
+			if err := e.renderBarcode(&bc); err != nil {
+				return err
+			}
+
+			width, height := barcodeSize(&bc)
+			childEndY := bc.Y + height
+			settle(childEndY)
+			childEndX := bc.X + width
+			if childEndX > currentX {
+				currentX = childEndX
+			}
+			e.pdf.SetXY(currentX, baseY)
+		case "chart", "sparkline", "progressBar":
+			width := rowGraphicWidth(elem)
+			if width <= 0 {
+				return fmt.Errorf("row %s requires width", elem.Type)
+			}
+
+			leftOffset := currentX - e.flowLeftMargin()
+			rightOffset := e.flowLeftMargin() + e.flowContentWidth() - (currentX + width)
+			_ = e.withFlowBounds(leftOffset, rightOffset, func() error {
+				return e.withinRow(func() error {
+					e.renderRowGraphic(elem)
+					return nil
+				})
+			})
+
+			settle(e.pdf.GetY())
+			currentX += width
+			e.pdf.SetXY(currentX, baseY)
+		case "rectangle":
+			if elem.Rectangle == nil {
+				continue
+			}
+
+			rect := *elem.Rectangle
+			rect.X = currentX + rect.X
+			rect.Y = baseY + rect.Y
+			rect.SpacingAfter = 0
+			e.renderRectangle(&rect)
+
+			childEndY := rect.Y + rect.Height
+			settle(childEndY)
+			childEndX := rect.X + rect.Width
+			if childEndX > currentX {
+				currentX = childEndX
+			}
+			e.pdf.SetXY(currentX, baseY)
+		case "pageBreak":
 			return fmt.Errorf("unsupported row child type: %s", elem.Type)
+		default:
+			// Other elements share the width the rest of the row leaves.
+			width := e.rowFlexWidth(visible[idx:], currentX)
+			if width <= 0 {
+				return fmt.Errorf("row %s width resolved to zero", elem.Type)
+			}
+
+			child := copyElement(elem)
+			if base := elementBase(child); base != nil {
+				base.SpacingAfter = 0
+			}
+			leftOffset := currentX - e.flowLeftMargin()
+			rightOffset := e.flowLeftMargin() + e.flowContentWidth() - (currentX + width)
+			render := func() error {
+				return e.withFlowBounds(leftOffset, rightOffset, func() error {
+					return e.renderElements([]models.SectionElement{child})
+				})
+			}
+			if endPage > startPage {
+				// Only a row taller than a page gets here. A second child
+				// that breaks across pages cannot go back to the first
+				// page, so it continues below the child that broke.
+				height, err := e.measureHeight(render)
+				if err != nil {
+					return err
+				}
+				if !e.fitsOnPage(height) {
+					e.pdf.SetPage(endPage)
+					e.pdf.SetXY(currentX, maxY)
+				}
+			}
+			if err := render(); err != nil {
+				return err
+			}
+
+			settle(e.pdf.GetY())
+			currentX += width
+			e.pdf.SetXY(currentX, baseY)
 		}
 	}
 
+	e.pdf.SetPage(endPage)
 	e.pdf.SetXY(baseX, maxY)
 	if row.SpacingAfter > 0 {
 		e.pdf.Ln(row.SpacingAfter)
@@ -393,6 +512,102 @@ func (e *Engine) renderRow(row *models.Row) error {
 	return nil
 }
 
+// isRowFlowChild reports whether a row child is laid out by the row as a
+// block of flow content rather than placed at its own size.
+func isRowFlowChild(elem models.SectionElement) bool {
+	switch elem.Type {
+	case "text", "image", "qrcode", "barcode", "chart", "sparkline", "progressBar", "rectangle", "pageBreak":
+		return false
+	}
+	return true
+}
+
+// rowFlexWidth returns the width of the first of children, a row child
+// without a width of its own, starting at x. The width left after the sized
+// children is shared equally by the rest.
+func (e *Engine) rowFlexWidth(children []models.SectionElement, x float64) float64 {
+	remaining := e.flowLeftMargin() + e.flowContentWidth() - x
+	flexible := 0
+	for _, elem := range children {
+		width, sized := e.rowChildWidth(elem)
+		if sized {
+			remaining -= width
+		} else {
+			flexible++
+		}
+	}
+	return remaining / float64(flexible)
+}
+
+// rowChildWidth returns the width a row child takes, including its x offset,
+// and whether it is sized by itself rather than by the row. Texts without a
+// width take the width of their content.
+func (e *Engine) rowChildWidth(elem models.SectionElement) (float64, bool) {
+	switch {
+	case elem.Text != nil:
+		if elem.Text.Width > 0 {
+			return elem.Text.X + elem.Text.Width, true
+		}
+		width := 0.0
+		e.withGraphicsState(func() {
+			e.applyStyle(elem.Text.Style)
+			if len(elem.Text.Runs) > 0 {
+				width = e.richTextWidth(elem.Text.Runs)
+			} else {
+				width = e.pdf.GetStringWidth(e.processTemplate(elem.Text.Content))
+			}
+		})
+		return elem.Text.X + width, true
+	case elem.Image != nil:
+		return elem.Image.X + elem.Image.Width, true
+	case elem.QRCode != nil:
+		return elem.QRCode.X + qrCodeSize(elem.QRCode), true
+	case elem.Barcode != nil:
+		width, _ := barcodeSize(elem.Barcode)
+		return elem.Barcode.X + width, true
+	case elem.Rectangle != nil:
+		return elem.Rectangle.X + elem.Rectangle.Width, true
+	case elem.Chart != nil, elem.Sparkline != nil, elem.Progress != nil:
+		return rowGraphicWidth(elem), true
+	default:
+		return 0, false
+	}
+}
+
+// rowGraphicWidth returns the width of a chart, sparkline or progress bar
+// row child, or 0 when it has none.
+func rowGraphicWidth(elem models.SectionElement) float64 {
+	switch {
+	case elem.Chart != nil:
+		return elem.Chart.Width
+	case elem.Sparkline != nil:
+		return elem.Sparkline.Width
+	case elem.Progress != nil:
+		return elem.Progress.Width
+	default:
+		return 0
+	}
+}
+
+// renderRowGraphic renders a chart, sparkline or progress bar row child
+// without its spacing, which rows do not apply to children.
+func (e *Engine) renderRowGraphic(elem models.SectionElement) {
+	switch {
+	case elem.Chart != nil:
+		chart := *elem.Chart
+		chart.SpacingAfter = 0
+		e.renderChart(&chart)
+	case elem.Sparkline != nil:
+		sparkline := *elem.Sparkline
+		sparkline.SpacingAfter = 0
+		e.renderSparkline(&sparkline)
+	case elem.Progress != nil:
+		progress := *elem.Progress
+		progress.SpacingAfter = 0
+		e.renderProgressBar(&progress)
+	}
+}
+
 func (e *Engine) resolveRowTextWidth(text *models.Text, isLast bool) float64 {
 	if text == nil {
 		return 0
@@ -412,7 +627,12 @@ func (e *Engine) resolveRowTextWidth(text *models.Text, isLast bool) float64 {
 		return remainingWidth
 	}
 
-	contentWidth := e.pdf.GetStringWidth(e.processTemplate(text.Content))
+	var contentWidth float64
+	if len(text.Runs) > 0 {
+		contentWidth = e.richTextWidth(text.Runs)
+	} else {
+		contentWidth = e.pdf.GetStringWidth(e.processTemplate(text.Content))
+	}
 	if contentWidth <= 0 {
 		return remainingWidth
 	}
@@ -423,39 +643,37 @@ func (e *Engine) resolveRowTextWidth(text *models.Text, isLast bool) float64 {
 	return contentWidth
 }
 
-// renderRowGrid renders equal-width columns and advances by the tallest column.
+// renderRowGrid renders columns side by side and advances by the tallest
+// column.
 func (e *Engine) renderRowGrid(rowGrid *models.RowGrid) error {
 	if rowGrid == nil {
 		return nil
 	}
 
-	columnCount := rowGrid.Columns
-	if columnCount < len(rowGrid.Cols) {
-		columnCount = len(rowGrid.Cols)
-	}
-	if columnCount <= 0 {
-		return fmt.Errorf("rowgrid requires at least one column")
-	}
-
 	totalWidth := e.flowContentWidth()
 	if totalWidth <= 0 {
 		return fmt.Errorf("rowgrid width resolved to zero")
 	}
+	widths, err := rowGridColumnWidths(rowGrid, totalWidth)
+	if err != nil {
+		return err
+	}
 
 	baseX := e.flowLeftMargin()
 	baseY := e.pdf.GetY()
-	columnWidth := totalWidth / float64(columnCount)
 	maxY := baseY
+	leftOffset := 0.0
 
-	for idx := 0; idx < columnCount; idx++ {
-		leftOffset := float64(idx) * columnWidth
+	for idx, columnWidth := range widths {
 		rightOffset := totalWidth - leftOffset - columnWidth
 
 		e.pdf.SetXY(baseX+leftOffset, baseY)
 
 		if idx < len(rowGrid.Cols) {
 			if err := e.withFlowBounds(leftOffset, rightOffset, func() error {
-				return e.renderElements(rowGrid.Cols[idx].Elements)
+				return e.withinRow(func() error {
+					return e.renderElements(rowGrid.Cols[idx].Elements)
+				})
 			}); err != nil {
 				return err
 			}
@@ -464,6 +682,24 @@ func (e *Engine) renderRowGrid(rowGrid *models.RowGrid) error {
 		if gotY := e.pdf.GetY(); gotY > maxY {
 			maxY = gotY
 		}
+		leftOffset += columnWidth + rowGrid.Gap
+	}
+
+	if rowGrid.Divider {
+		e.withGraphicsState(func() {
+			if rowGrid.DividerColor != "" {
+				e.pdf.SetDrawColor(models.ParseColor(rowGrid.DividerColor))
+			}
+			if rowGrid.DividerWidth > 0 {
+				e.pdf.SetLineWidth(rowGrid.DividerWidth)
+			}
+			x := baseX
+			for _, columnWidth := range widths[:len(widths)-1] {
+				x += columnWidth + rowGrid.Gap/2
+				e.pdf.Line(x, baseY, x, maxY)
+				x += rowGrid.Gap / 2
+			}
+		})
 	}
 
 	e.pdf.SetXY(baseX, maxY)
@@ -474,6 +710,60 @@ func (e *Engine) renderRowGrid(rowGrid *models.RowGrid) error {
 	return nil
 }
 
+// rowGridColumnWidths resolves the column widths of a rowgrid within
+// totalWidth. Fixed column widths are taken first, then the other columns
+// share what is left, less the gaps, in proportion to their weight: the
+// column weight, else its entry in widths, else 1. It returns an error when
+// the fixed widths and gaps leave no room for the other columns.
+func rowGridColumnWidths(rowGrid *models.RowGrid, totalWidth float64) ([]float64, error) {
+	var weights []float64
+	if spec := strings.TrimSpace(rowGrid.Widths); spec != "" {
+		for _, part := range strings.Split(spec, ",") {
+			weight := 1.0
+			if value, err := strconv.ParseFloat(strings.TrimSpace(part), 64); err == nil && value > 0 {
+				weight = value
+			}
+			weights = append(weights, weight)
+		}
+	}
+
+	columnCount := max(rowGrid.Columns, len(rowGrid.Cols), len(weights))
+	if columnCount <= 0 {
+		return nil, fmt.Errorf("rowgrid requires at least one column")
+	}
+
+	widths := make([]float64, columnCount)
+	columnWeights := make([]float64, columnCount)
+	remaining := totalWidth - rowGrid.Gap*float64(columnCount-1)
+	totalWeight := 0.0
+	for idx := range widths {
+		if idx < len(rowGrid.Cols) && rowGrid.Cols[idx].Width > 0 {
+			widths[idx] = rowGrid.Cols[idx].Width
+			remaining -= widths[idx]
+			continue
+		}
+
+		weight := 1.0
+		if idx < len(rowGrid.Cols) && rowGrid.Cols[idx].Weight > 0 {
+			weight = rowGrid.Cols[idx].Weight
+		} else if idx < len(weights) {
+			weight = weights[idx]
+		}
+		columnWeights[idx] = weight
+		totalWeight += weight
+	}
+
+	if remaining < 0 || remaining == 0 && totalWeight > 0 {
+		return nil, fmt.Errorf("rowgrid columns and gaps exceed the flow width")
+	}
+	for idx, weight := range columnWeights {
+		if weight > 0 {
+			widths[idx] = remaining * weight / totalWeight
+		}
+	}
+	return widths, nil
+}
+
 // renderLine renders a line element.
 func (e *Engine) renderLine(line *models.Line) {
 	pageWidth, pageHeight := e.pdf.GetPageSize()
@@ -578,31 +868,58 @@ func (e *Engine) renderRectangle(rect *models.Rectangle) {
 	}
 }
 
-// extractDataKey extracts the data key from a template string like "{{.SalesData}}"
-func extractDataKey(tmpl string) string {
-	if len(tmpl) < 5 {
-		return tmpl
-	}
-	// Remove {{ and }}
-	key := tmpl
-	if len(key) > 2 && key[:2] == "{{" {
-		key = key[2:]
-	}
-	if len(key) > 2 && key[len(key)-2:] == "}}" {
-		key = key[:len(key)-2]
+// splitTextLines wraps text into lines that fit within a cell of the given
+// width using the current font. Explicit newlines are preserved and words
+// longer than the width are broken between characters. The result always
+// contains at least one line.
+func (e *Engine) splitTextLines(text string, width float64) []string {
+	available := width - 2*e.pdf.GetCellMargin()
+	if available <= 0 {
+		available = width
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		current := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if current != "" {
+				candidate = current + " " + word
+			}
+			if e.pdf.GetStringWidth(candidate) <= available {
+				current = candidate
+				continue
+			}
+
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			for e.pdf.GetStringWidth(word) > available {
+				head := breakWordToWidth(word, available, e.pdf.GetStringWidth)
+				lines = append(lines, head)
+				word = word[len(head):]
+			}
+			current = word
+		}
+		lines = append(lines, current)
 	}
-	// Remove leading . and spaces
-	key = trimPrefix(key, ".")
-	key = trimSpace(key)
-	return key
+
+	return lines
 }
 
-func trimPrefix(s, prefix string) string {
-	for len(s) > 0 && len(prefix) > 0 && s[0] == prefix[0] {
-		s = s[1:]
-		break
+// breakWordToWidth returns the longest prefix of word, at least one rune long,
+// whose measured width fits within width.
+func breakWordToWidth(word string, width float64, measure func(string) float64) string {
+	end := 0
+	for idx, r := range word {
+		next := idx + utf8.RuneLen(r)
+		if end > 0 && measure(word[:next]) > width {
+			break
+		}
+		end = next
 	}
-	return s
+	return word[:end]
 }
 
 func trimSpace(s string) string {
@@ -615,7 +932,8 @@ func trimSpace(s string) string {
 	return s
 }
 
-// formatValue formats a value based on the column format.
+// formatValue formats a value based on the column format. Values other than
+// numbers, strings, booleans and fmt.Stringers format as "".
 func formatValue(val interface{}, format string) string {
 	if val == nil {
 		return ""
@@ -634,14 +952,43 @@ func formatValue(val interface{}, format string) string {
 			return formatNumber(v)
 		}
 	case int:
-		return formatNumber(float64(v))
-	default:
-		return ""
+		return formatValue(float64(v), format)
+	case fmt.Stringer:
+		return v.String()
 	}
+
+	if number, ok := toFloat64(val); ok {
+		return formatValue(number, format)
+	}
+
+	// Named types of basic kinds, such as enums, print their value.
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return formatValue(float64(rv.Int()), format)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return formatValue(float64(rv.Uint()), format)
+	case reflect.Float32, reflect.Float64:
+		return formatValue(rv.Float(), format)
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool())
+	}
+
+	return ""
 }
 
 func formatCurrency(v float64) string {
-	return "$" + formatNumber(v)
+	return currencyString("$", formatNumber(v))
+}
+
+// currencyString puts symbol before a formatted amount, after its minus sign.
+func currencyString(symbol, amount string) string {
+	if digits, negative := strings.CutPrefix(amount, "-"); negative {
+		return "-" + symbol + digits
+	}
+	return symbol + amount
 }
 
 func formatPercent(v float64) string {
@@ -679,7 +1026,18 @@ func intToString(n int) string {
 	return string(digits)
 }
 
+// floatToString formats f with the given number of decimals. Negative
+// numbers format their magnitude after a minus sign, which is left out when
+// they round to zero.
 func floatToString(f float64, decimals int) string {
+	if f < 0 {
+		magnitude := floatToString(-f, decimals)
+		if strings.Trim(magnitude, "0.") == "" {
+			return magnitude
+		}
+		return "-" + magnitude
+	}
+
 	// Multiply to shift decimal places
 	multiplier := 1.0
 	for i := 0; i < decimals; i++ {
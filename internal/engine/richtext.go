@@ -0,0 +1,270 @@
+// Package engine provides inline markup rendering functionality.
+package engine
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+// richFragment is a word or a space of rich text set in one run format.
+type richFragment struct {
+	text   string
+	format *models.TextRun
+	width  float64
+	// note is set on footnote markers.
+	note *footnote
+}
+
+// richLine is a line of fragments laid out by layoutRichText.
+type richLine struct {
+	fragments []richFragment
+	width     float64
+	height    float64
+}
+
+// renderRichText draws text runs at the current position in a box of the
+// given width, wrapping across runs when wrap is set, and leaves Y below the
+// last line. Wrapped lines break across pages as planned by planLineBreaks.
+// It returns the page and Y position of the first line.
+func (e *Engine) renderRichText(runs []models.TextRun, width float64, wrap bool, align string, lineHeight float64, minBefore, minAfter int) (int, float64) {
+	left, y := e.pdf.GetXY()
+	startPage, startY := e.pdf.PageNo(), y
+	cellMargin := e.pdf.GetCellMargin()
+	autoPageBreak, _ := e.pdf.GetAutoPageBreak()
+
+	e.withGraphicsState(func() {
+		base := e.richBaseFormat()
+
+		maxWidth := 0.0
+		if wrap {
+			maxWidth = width - 2*cellMargin
+		}
+		lines := e.layoutRichText(runs, base, maxWidth, lineHeight)
+
+		var breaks []int
+		if wrap {
+			heights := make([]float64, len(lines))
+			for idx, line := range lines {
+				heights[idx] = line.height
+			}
+			breaks = e.planLineBreaks(heights, minBefore, minAfter)
+		}
+
+		e.pdf.SetCellMargin(0)
+		defer e.pdf.SetCellMargin(cellMargin)
+
+		var format *models.TextRun
+		for idx, line := range lines {
+			// A line with footnote markers needs room for its notes on the
+			// same page.
+			notes := line.notes()
+			planned := len(breaks) > 0 && breaks[0] == idx
+			if planned {
+				breaks = breaks[1:]
+			}
+			if planned || autoPageBreak && y+line.height > e.pageBreakTrigger()-e.footnotesHeight(notes) {
+				e.pdf.AddPage()
+				y = e.pdf.GetY()
+				// The page header may have changed the font.
+				format = nil
+			}
+			if idx == 0 {
+				startPage, startY = e.pdf.PageNo(), y
+			}
+			e.addFootnotes(notes)
+
+			x := left + cellMargin
+			switch align {
+			case "C":
+				x += (width - 2*cellMargin - line.width) / 2
+			case "R":
+				x += width - 2*cellMargin - line.width
+			}
+
+			for _, fragment := range line.fragments {
+				if fragment.format != format {
+					e.applyRunFormat(base, fragment.format)
+					format = fragment.format
+				}
+				if fragment.note != nil {
+					e.drawFootnoteMarker(fragment, x, y, line.height)
+					// The smaller marker font must not carry over.
+					format = nil
+				} else {
+					e.pdf.SetXY(x, y)
+					e.pdf.CellFormat(fragment.width, line.height, fragment.text, "", 0, "L", false, 0, "")
+				}
+				x += fragment.width
+			}
+			y += line.height
+		}
+	})
+
+	e.pdf.SetY(y)
+	return startPage, startY
+}
+
+// richTextWidth returns the width of the widest line of runs without
+// wrapping, including cell margins.
+func (e *Engine) richTextWidth(runs []models.TextRun) float64 {
+	width := 0.0
+	e.withGraphicsState(func() {
+		for _, line := range e.layoutRichText(runs, e.richBaseFormat(), 0, 0) {
+			if line.width > width {
+				width = line.width
+			}
+		}
+	})
+	return width + 2*e.pdf.GetCellMargin()
+}
+
+// richBaseFormat captures the current font and text color, which runs
+// format on top of.
+type richBaseFormat struct {
+	family  string
+	style   string
+	size    float64
+	r, g, b int
+}
+
+func (e *Engine) richBaseFormat() richBaseFormat {
+	size, _ := e.pdf.GetFontSize()
+	r, g, b := e.pdf.GetTextColor()
+	return richBaseFormat{family: e.fontFamily, style: e.fontStyle, size: size, r: r, g: g, b: b}
+}
+
+// applyRunFormat resets the font and text color to base, then applies the
+// run style and inline bold, italic and underline.
+func (e *Engine) applyRunFormat(base richBaseFormat, run *models.TextRun) {
+	e.setFont(base.family, base.style, base.size)
+	e.pdf.SetTextColor(base.r, base.g, base.b)
+	if run.Style != "" {
+		e.applyStyle(run.Style)
+	}
+
+	style := e.fontStyle
+	if run.Bold {
+		style += "B"
+	}
+	if run.Italic {
+		style += "I"
+	}
+	if run.Underline {
+		style += "U"
+	}
+	if style != e.fontStyle {
+		size, _ := e.pdf.GetFontSize()
+		e.setFont(e.fontFamily, normalizeFontStyle(style), size)
+	}
+}
+
+// normalizeFontStyle removes repeated letters from a font style.
+func normalizeFontStyle(style string) string {
+	var normalized strings.Builder
+	for _, letter := range "BIU" {
+		if strings.ContainsRune(strings.ToUpper(style), letter) {
+			normalized.WriteRune(letter)
+		}
+	}
+	return normalized.String()
+}
+
+// layoutRichText splits runs into words and single spaces and breaks them
+// into lines no wider than maxWidth, or only at <br/> when maxWidth is 0.
+// Whitespace, including newlines, collapses to one space as in HTML. Each
+// line is as tall as the largest line height of its runs.
+func (e *Engine) layoutRichText(runs []models.TextRun, base richBaseFormat, maxWidth, lineHeight float64) []richLine {
+	lines := []richLine{{height: lineHeight}}
+	var pendingSpace *richFragment
+
+	addFragment := func(fragment richFragment, height float64) {
+		line := &lines[len(lines)-1]
+		if pendingSpace != nil && len(line.fragments) > 0 {
+			if maxWidth > 0 && line.width+pendingSpace.width+fragment.width > maxWidth {
+				lines = append(lines, richLine{height: lineHeight})
+				line = &lines[len(lines)-1]
+			} else {
+				line.fragments = append(line.fragments, *pendingSpace)
+				line.width += pendingSpace.width
+			}
+		}
+		pendingSpace = nil
+
+		line.fragments = append(line.fragments, fragment)
+		line.width += fragment.width
+		if height > line.height {
+			line.height = height
+		}
+	}
+
+	nextNote := e.footnoteCount
+	for idx := range runs {
+		run := &runs[idx]
+		if run.Footnote != "" || run.FootnoteRef != "" {
+			nextNote++
+			e.applyRunFormat(base, run)
+			addFragment(e.footnoteMarker(run, nextNote, base.family), lineHeight)
+			continue
+		}
+		if run.Break {
+			lines = append(lines, richLine{height: lineHeight})
+			pendingSpace = nil
+			continue
+		}
+
+		e.applyRunFormat(base, run)
+		height := lineHeight
+		if style, ok := e.styles[run.Style]; ok && style.LineHeight > height {
+			height = style.LineHeight
+		}
+
+		content := e.processTemplate(run.Content)
+		for len(content) > 0 {
+			if strings.IndexFunc(content, unicode.IsSpace) == 0 {
+				content = strings.TrimLeftFunc(content, unicode.IsSpace)
+				if len(lines[len(lines)-1].fragments) > 0 {
+					pendingSpace = &richFragment{text: " ", format: run, width: e.pdf.GetStringWidth(" ")}
+				}
+				continue
+			}
+
+			end := strings.IndexFunc(content, unicode.IsSpace)
+			if end < 0 {
+				end = len(content)
+			}
+			word := content[:end]
+			content = content[end:]
+
+			for pieceIdx, piece := range e.splitRichWord(word, maxWidth) {
+				if pieceIdx > 0 {
+					lines = append(lines, richLine{height: lineHeight})
+				}
+				addFragment(richFragment{text: piece, format: run, width: e.pdf.GetStringWidth(piece)}, height)
+			}
+		}
+	}
+
+	return lines
+}
+
+// splitRichWord breaks a word wider than maxWidth into pieces that fit, in
+// the current font.
+func (e *Engine) splitRichWord(word string, maxWidth float64) []string {
+	if maxWidth <= 0 || e.pdf.GetStringWidth(word) <= maxWidth {
+		return []string{word}
+	}
+
+	var pieces []string
+	start := 0
+	for idx, r := range word {
+		end := idx + utf8.RuneLen(r)
+		if idx > start && e.pdf.GetStringWidth(word[start:end]) > maxWidth {
+			pieces = append(pieces, word[start:idx])
+			start = idx
+		}
+	}
+	return append(pieces, word[start:])
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+// textPosition returns the x position, in points, at which content is
+// drawn in the PDF output.
+func textPosition(t *testing.T, output, content string) float64 {
+	t.Helper()
+
+	match := regexp.MustCompile(`BT ([0-9.]+) [0-9.]+ Td \(` + regexp.QuoteMeta(content) + `\) ?Tj`).FindStringSubmatch(output)
+	if match == nil {
+		t.Fatalf("expected %q to be drawn", content)
+	}
+	x, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		t.Fatalf("invalid text position %q: %v", match[1], err)
+	}
+	return x
+}
+
+func TestRenderTextDrawsInlineRuns(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Name": "Ada"})
+	engine.styles["alert"] = &models.Style{Name: "alert", TextColor: &models.RGBColor{R: 255}}
+
+	engine.renderText(&models.Text{
+		Style: "body",
+		Width: 150,
+		Runs: []models.TextRun{
+			{Content: "Dear "},
+			{Content: "{{.Name}}", Bold: true},
+			{Content: ", please "},
+			{Content: "pay now", Style: "alert", Underline: true},
+		},
+	})
+
+	output := renderedPDF(t, engine)
+	for _, want := range []string{"(Dear)", "(Ada)", "(please)", "(pay)", "(now)"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %s in output", want)
+		}
+	}
+	if !strings.Contains(output, "/BaseFont /Helvetica-Bold") {
+		t.Fatalf("expected bold run to use the bold font")
+	}
+	if !strings.Contains(output, "1.000 0.000 0.000 rg") {
+		t.Fatalf("expected span style color in output")
+	}
+	if engine.fontStyle != "" {
+		t.Fatalf("expected font style to be restored, got %q", engine.fontStyle)
+	}
+}
+
+func TestLayoutRichTextWrapsAcrossRuns(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	engine.applyStyle("body")
+
+	runs := []models.TextRun{
+		{Content: "The quick brown "},
+		{Content: "fox jumps", Bold: true},
+		{Content: "\n   over the lazy dog"},
+	}
+	lines := engine.layoutRichText(runs, engine.richBaseFormat(), 30, 5)
+	if len(lines) < 3 {
+		t.Fatalf("expected text to wrap onto several lines, got %d", len(lines))
+	}
+
+	var words []string
+	for _, line := range lines {
+		if line.width > 30 {
+			t.Fatalf("line %v is %.2f wide, exceeding 30", line.fragments, line.width)
+		}
+		for idx, fragment := range line.fragments {
+			if fragment.text == " " && (idx == 0 || idx == len(line.fragments)-1) {
+				t.Fatalf("expected no space at the edge of a line, got %v", line.fragments)
+			}
+			if fragment.text != " " {
+				words = append(words, fragment.text)
+			}
+		}
+	}
+	if got := strings.Join(words, " "); got != "The quick brown fox jumps over the lazy dog" {
+		t.Fatalf("expected whitespace to collapse between words, got %q", got)
+	}
+}
+
+func TestRenderTextHonorsBreaksAndAlignment(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	engine.pdf.SetCellMargin(0)
+
+	startY := engine.pdf.GetY()
+	engine.renderText(&models.Text{
+		Style: "body",
+		X:     10,
+		Width: 100,
+		Align: "R",
+		Runs: []models.TextRun{
+			{Content: "first"},
+			{Break: true},
+			{Content: "end", Italic: true},
+		},
+	})
+
+	if got, want := engine.pdf.GetY(), startY+10; math.Abs(got-want) > 0.001 {
+		t.Fatalf("expected two lines of height 5 ending at %.2f, got %.2f", want, got)
+	}
+
+	engine.applyStyle("body")
+	engine.setFont("Arial", "I", 12)
+	width := engine.pdf.GetStringWidth("end")
+	output := renderedPDF(t, engine)
+
+	scale := engine.pdf.GetConversionRatio()
+	if got, want := textPosition(t, output, "end"), (110-width)*scale; math.Abs(got-want) > 0.01 {
+		t.Fatalf("expected right-aligned text at %s, got %.2f", fmt.Sprintf("%.2f", want), got)
+	}
+}
+
+func TestResolveRowTextWidthMeasuresRuns(t *testing.T) {
+	engine := newTestEngine(t, nil)
+
+	text := &models.Text{X: 10, Runs: []models.TextRun{{Content: "short"}, {Content: " bold", Bold: true}}}
+	plain := engine.pdf.GetStringWidth("short bold")
+	if got := engine.resolveRowTextWidth(text, false); got < plain || got > plain+10 {
+		t.Fatalf("expected width close to the text width %.2f, got %.2f", plain, got)
+	}
+}
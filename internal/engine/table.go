@@ -0,0 +1,830 @@
+// Package engine provides table rendering functionality.
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+// defaultTableRowHeight is the height of header and data rows.
+const defaultTableRowHeight = 7.0
+
+// tableCell is a single laid-out cell of a table row. Optional colors
+// override the row colors for this cell only. Cells of sparkline and
+// progress bar columns carry a graphic instead of text.
+type tableCell struct {
+	width     float64
+	text      string
+	align     string
+	wrap      bool
+	textColor *models.RGBColor
+	fillColor *models.RGBColor
+
+	graphic  string
+	values   []float64
+	fraction float64
+	color    string
+}
+
+// tableLayout is a table with its column widths resolved against the
+// current flow bounds.
+type tableLayout struct {
+	*models.Table
+	widths []float64
+}
+
+// tableRowGroup is a run of data rows sharing the same groupBy value.
+type tableRowGroup struct {
+	key  interface{}
+	rows []interface{}
+}
+
+// renderTable renders a table element. Data rows only split across pages
+// when they are taller than a page; when a row does not fit, a new page is
+// started and the header row is drawn again unless the table opts out with
+// repeatHeader="false".
+func (e *Engine) renderTable(source *models.Table) {
+	e.renderTableRows(source, e.resolveTableRows(source))
+}
+
+// renderTableRows renders a table from already resolved rows.
+func (e *Engine) renderTableRows(source *models.Table, rows []interface{}) {
+	groups := groupTableRows(source, rows)
+	table := e.layoutTable(source, rows)
+
+	// Keep the header with at least the first body row, or the part of it
+	// that fits on a page.
+	firstRowHeight := 0.0
+	if len(rows) > 0 {
+		e.applyTableCellStyle(table)
+		firstRowHeight = min(e.tableCellsHeight(table, e.tableDataCells(table, rows[0], 0)), e.tableMaxRowHeight(table))
+		if table.GroupBy != nil {
+			firstRowHeight += defaultTableRowHeight
+		}
+	}
+	if !e.fitsOnPage(table.headerHeight() + firstRowHeight) {
+		e.pdf.AddPage()
+	}
+	e.defineAnchor(source.Anchor)
+
+	e.renderTableHeader(table)
+
+	rowIndex := 0
+	for _, group := range groups {
+		if table.GroupBy != nil {
+			e.renderTableGroupHeader(table, group, rowIndex)
+		}
+
+		for _, row := range group.rows {
+			e.renderTableDataRow(table, row, rowIndex)
+			rowIndex++
+		}
+
+		if table.GroupBy != nil && table.Footer != nil && boolValue(table.GroupBy.Subtotals, true) {
+			e.renderTableSummaryRow(table, group, true)
+		}
+	}
+
+	if table.Footer != nil {
+		e.renderTableSummaryRow(table, tableRowGroup{rows: rows}, false)
+	}
+
+	if table.SpacingAfter > 0 {
+		e.pdf.Ln(table.SpacingAfter)
+	}
+}
+
+// ensureTableSpace starts a new page when a block of the given height does
+// not fit, repeating the header row unless the table opts out.
+func (e *Engine) ensureTableSpace(table *tableLayout, height float64) {
+	if e.fitsOnPage(height) {
+		return
+	}
+
+	e.pdf.AddPage()
+	if boolValue(table.RepeatHeader, true) {
+		e.renderTableContinuedCaption(table)
+		e.renderTableHeader(table)
+	}
+}
+
+// renderTableDataRow draws one data row, moving it to a new page first when
+// it does not fit. A row taller than a page is split between its wrapped
+// lines and continues on the next pages.
+func (e *Engine) renderTableDataRow(table *tableLayout, row interface{}, index int) {
+	e.applyTableCellStyle(table)
+	cells := e.tableDataCells(table, row, index)
+	rowHeight := e.tableCellsHeight(table, cells)
+	style := e.matchTableRowStyle(table, row, index)
+
+	textR, textG, textB := e.pdf.GetTextColor()
+	defer e.pdf.SetTextColor(textR, textG, textB)
+	for _, part := range e.splitTableRow(table, cells, rowHeight) {
+		e.ensureTableSpace(table, part.height)
+		e.applyTableCellStyle(table)
+
+		// Alternate row colors
+		fill := false
+		if table.AlternateRowColor != nil && index%2 == 0 {
+			r, g, b := table.AlternateRowColor.ToRGB()
+			e.pdf.SetFillColor(r, g, b)
+			fill = true
+		}
+
+		// Conditional row styles override the alternate row color.
+		if style != nil {
+			if style.TextColor != nil {
+				r, g, b := style.TextColor.ToRGB()
+				e.pdf.SetTextColor(r, g, b)
+			}
+			if style.FillColor != nil {
+				r, g, b := style.FillColor.ToRGB()
+				e.pdf.SetFillColor(r, g, b)
+				fill = true
+			}
+		}
+
+		e.drawTableCells(table, part.cells, part.height, fill)
+	}
+}
+
+// tableRowPart is the part of a data row drawn on one page.
+type tableRowPart struct {
+	cells  []tableCell
+	height float64
+}
+
+// splitTableRow splits a row of the given height into parts that each fit
+// on a page below the repeated header. Wrapped cells continue with their
+// remaining lines in later parts; other cells are drawn in the first part
+// and left empty after it.
+func (e *Engine) splitTableRow(table *tableLayout, cells []tableCell, rowHeight float64) []tableRowPart {
+	maxHeight := e.tableMaxRowHeight(table)
+	lineHeight := e.tableCellLineHeight(table)
+	if rowHeight <= maxHeight || maxHeight < lineHeight {
+		return []tableRowPart{{cells: cells, height: rowHeight}}
+	}
+
+	linesPerPart := int(maxHeight / lineHeight)
+	lines := make([][]string, len(cells))
+	partCount := 1
+	for idx, cell := range cells {
+		if cell.wrap {
+			lines[idx] = e.splitTextLines(cell.text, cell.width)
+			partCount = max(partCount, (len(lines[idx])+linesPerPart-1)/linesPerPart)
+		}
+	}
+
+	parts := make([]tableRowPart, partCount)
+	for partIdx := range parts {
+		partCells := make([]tableCell, len(cells))
+		for idx, cell := range cells {
+			if cell.wrap {
+				start := min(partIdx*linesPerPart, len(lines[idx]))
+				end := min(start+linesPerPart, len(lines[idx]))
+				cell.text = strings.Join(lines[idx][start:end], "\n")
+			} else if partIdx > 0 {
+				cell.text = ""
+				cell.graphic = ""
+			}
+			partCells[idx] = cell
+		}
+		parts[partIdx] = tableRowPart{cells: partCells, height: e.tableCellsHeight(table, partCells)}
+	}
+	return parts
+}
+
+// tableMaxRowHeight returns the height left for body rows on a new page,
+// below the continued caption and header row when they are repeated.
+func (e *Engine) tableMaxRowHeight(table *tableLayout) float64 {
+	height := e.pageContentHeight()
+	if boolValue(table.RepeatHeader, true) {
+		height -= table.headerHeight()
+		if e.processTemplate(table.ContinuedCaption) != "" {
+			height -= e.tableCellLineHeight(table)
+		}
+	}
+	return height
+}
+
+// matchTableRowStyle returns the style of the last rowStyle rule whose
+// condition holds for the row, or nil when none match.
+func (e *Engine) matchTableRowStyle(table *tableLayout, row interface{}, index int) *models.Style {
+	var matched *models.Style
+	_ = e.withTableRowScope(row, index, func() error {
+		for _, rule := range table.RowStyles {
+			style, ok := e.styles[rule.Style]
+			if ok && e.shouldRenderCondition(rule.Condition) {
+				matched = style
+			}
+		}
+		return nil
+	})
+	return matched
+}
+
+// renderTableGroupHeader draws the full-width label row that opens a group,
+// keeping it together with the group's first data row.
+func (e *Engine) renderTableGroupHeader(table *tableLayout, group tableRowGroup, firstRowIndex int) {
+	style := table.GroupBy.Style
+	if style == "" {
+		style = table.CellStyle
+	}
+
+	nextRowHeight := 0.0
+	if len(group.rows) > 0 {
+		e.applyTableCellStyle(table)
+		nextRowHeight = min(e.tableCellsHeight(table, e.tableDataCells(table, group.rows[0], firstRowIndex)), e.tableMaxRowHeight(table)-defaultTableRowHeight)
+	}
+	e.ensureTableSpace(table, defaultTableRowHeight+nextRowHeight)
+
+	label := table.GroupBy.Label
+	if label == "" {
+		label = "{{.group}}"
+	}
+
+	var text string
+	_ = e.withScopedValues(map[string]interface{}{
+		"group":      group.key,
+		"groupCount": len(group.rows),
+	}, func() error {
+		text = e.processTemplate(label)
+		return nil
+	})
+
+	if style != "" {
+		e.applyStyle(style)
+	}
+	e.drawTableCells(table, []tableCell{{
+		width: table.width(),
+		text:  text,
+		align: "L",
+	}}, defaultTableRowHeight, e.styleHasFill(style))
+}
+
+// renderTableSummaryRow draws a subtotal row for a group or the grand total
+// row for the whole table using the footer cell definitions.
+func (e *Engine) renderTableSummaryRow(table *tableLayout, group tableRowGroup, subtotal bool) {
+	style := table.Footer.Style
+	if subtotal && table.Footer.SubtotalStyle != "" {
+		style = table.Footer.SubtotalStyle
+	}
+	if style == "" {
+		style = table.CellStyle
+	}
+
+	cells := e.tableSummaryCells(table, group, subtotal)
+
+	if style != "" {
+		e.applyStyle(style)
+	}
+	rowHeight := e.tableCellsHeight(table, cells)
+	e.ensureTableSpace(table, rowHeight)
+	if style != "" {
+		e.applyStyle(style)
+	}
+
+	e.drawTableCells(table, cells, rowHeight, e.styleHasFill(style))
+}
+
+// tableSummaryCells builds the cells of a subtotal or grand total row. Footer
+// cells map to columns by position, each spanning its colspan; cells with an
+// aggregate compute it from the group's rows and the rest render their label
+// template. Columns left over after the last footer cell stay empty.
+func (e *Engine) tableSummaryCells(table *tableLayout, group tableRowGroup, subtotal bool) []tableCell {
+	scope := map[string]interface{}{"groupCount": len(group.rows)}
+	if subtotal {
+		scope["group"] = group.key
+	}
+
+	columns := table.Columns.Columns
+	cells := make([]tableCell, 0, len(columns))
+	column := 0
+	for _, footerCell := range table.Footer.Cells {
+		if column >= len(columns) {
+			break
+		}
+
+		col := columns[column]
+		span := tableSpan(footerCell.Colspan, len(columns)-column)
+		cell := tableCell{width: table.spanWidth(column, span), align: col.Align}
+		if footerCell.Align != "" {
+			cell.align = footerCell.Align
+		}
+
+		if footerCell.Aggregate != "" {
+			field := footerCell.Field
+			if field == "" {
+				field = col.Field
+			}
+			format := footerCell.Format
+			if format == "" {
+				format = col.Format
+			}
+			if value, ok := aggregateTableRows(group.rows, field, footerCell.Aggregate); ok {
+				cell.text = formatValue(value, format)
+			}
+		} else {
+			label := footerCell.Label
+			if subtotal && footerCell.SubtotalLabel != "" {
+				label = footerCell.SubtotalLabel
+			}
+			_ = e.withScopedValues(scope, func() error {
+				cell.text = e.processTemplate(label)
+				return nil
+			})
+		}
+
+		cells = append(cells, cell)
+		column += span
+	}
+
+	for ; column < len(columns); column++ {
+		cells = append(cells, tableCell{width: table.widths[column], align: columns[column].Align})
+	}
+
+	return cells
+}
+
+// aggregateTableRows computes sum, avg, count, min or max of a field across
+// rows. Non-numeric values are ignored by every function except count.
+func aggregateTableRows(rows []interface{}, field, function string) (float64, bool) {
+	function = strings.ToLower(strings.TrimSpace(function))
+	if function == "count" {
+		return float64(len(rows)), true
+	}
+
+	var total, minValue, maxValue float64
+	count := 0
+	for _, row := range rows {
+		fieldValue, _ := resolveDataPath(row, field)
+		value, ok := toFloat64(fieldValue)
+		if !ok {
+			continue
+		}
+		if count == 0 || value < minValue {
+			minValue = value
+		}
+		if count == 0 || value > maxValue {
+			maxValue = value
+		}
+		total += value
+		count++
+	}
+
+	if count == 0 {
+		return 0, false
+	}
+
+	switch function {
+	case "sum":
+		return total, true
+	case "avg", "average":
+		return total / float64(count), true
+	case "min":
+		return minValue, true
+	case "max":
+		return maxValue, true
+	default:
+		return 0, false
+	}
+}
+
+// groupTableRows splits rows by the groupBy field, keeping groups in order of
+// first appearance. Without groupBy all rows form a single group.
+func groupTableRows(table *models.Table, rows []interface{}) []tableRowGroup {
+	if table.GroupBy == nil || table.GroupBy.Field == "" {
+		return []tableRowGroup{{rows: rows}}
+	}
+
+	var groups []tableRowGroup
+	index := make(map[string]int)
+	for _, row := range rows {
+		key, _ := resolveDataPath(row, table.GroupBy.Field)
+		id := fmt.Sprint(key)
+		if idx, ok := index[id]; ok {
+			groups[idx].rows = append(groups[idx].rows, row)
+			continue
+		}
+		index[id] = len(groups)
+		groups = append(groups, tableRowGroup{key: key, rows: []interface{}{row}})
+	}
+
+	return groups
+}
+
+// tableDataCells formats the cells of a data row. Column fields are resolved
+// as dotted paths so they work on maps, structs and nested values alike.
+// Columns with a body template render it with the row in scope instead.
+func (e *Engine) tableDataCells(table *tableLayout, row interface{}, index int) []tableCell {
+	cells := make([]tableCell, len(table.Columns.Columns))
+	for idx, col := range table.Columns.Columns {
+		cells[idx] = tableCell{
+			width: table.widths[idx],
+			align: col.Align,
+			wrap:  col.Wrap,
+		}
+
+		switch col.Type {
+		case "sparkline":
+			value, _ := resolveDataPath(row, col.Field)
+			cells[idx].graphic = col.Type
+			cells[idx].values = sparklineValues(toInterfaceSlice(value), "")
+			cells[idx].color = col.Color
+		case "progressBar":
+			value, _ := resolveDataPath(row, col.Field)
+			number, _ := toFloat64(value)
+			cells[idx].graphic = col.Type
+			cells[idx].fraction = progressFraction(number, col.Max)
+			cells[idx].color = col.Color
+		default:
+			cells[idx].text = e.tableCellText(col, row, index)
+		}
+
+		if style := e.matchTableCellStyle(col, row, index); style != nil {
+			cells[idx].textColor = style.TextColor
+			cells[idx].fillColor = style.FillColor
+		}
+	}
+	return cells
+}
+
+// matchTableCellStyle returns the column cellStyle when its condition holds
+// for the row. A column without cellStyleCondition always applies it.
+func (e *Engine) matchTableCellStyle(col models.Column, row interface{}, index int) *models.Style {
+	style, ok := e.styles[col.CellStyle]
+	if !ok {
+		return nil
+	}
+
+	matched := true
+	_ = e.withTableRowScope(row, index, func() error {
+		matched = e.shouldRenderCondition(col.CellStyleCondition)
+		return nil
+	})
+	if !matched {
+		return nil
+	}
+
+	return style
+}
+
+// tableCellText returns the display text of a data cell.
+func (e *Engine) tableCellText(col models.Column, row interface{}, index int) string {
+	cellTemplate := strings.TrimSpace(col.Template)
+	if cellTemplate == "" {
+		value, _ := resolveDataPath(row, col.Field)
+		return formatValue(value, col.Format)
+	}
+
+	// A cell whose template fails, for example on a missing field, is left
+	// empty rather than showing the template.
+	var text string
+	_ = e.withTableRowScope(row, index, func() error {
+		text, _ = e.executeTemplate(cellTemplate)
+		return nil
+	})
+	return text
+}
+
+// withTableRowScope exposes the current row to templates as .row, together
+// with its zero-based .rowIndex and one-based .rowNumber.
+func (e *Engine) withTableRowScope(row interface{}, index int, fn func() error) error {
+	return e.withScopedValues(map[string]interface{}{
+		"row":       row,
+		"rowIndex":  index,
+		"rowNumber": index + 1,
+	}, fn)
+}
+
+// tableCellsHeight returns the height of a row, growing to fit the tallest
+// wrapped cell. It expects the row's style to be applied already.
+func (e *Engine) tableCellsHeight(table *tableLayout, cells []tableCell) float64 {
+	rowHeight := defaultTableRowHeight
+	lineHeight := e.tableCellLineHeight(table)
+	for _, cell := range cells {
+		if !cell.wrap {
+			continue
+		}
+		height := float64(len(e.splitTextLines(cell.text, cell.width))) * lineHeight
+		if height > rowHeight {
+			rowHeight = height
+		}
+	}
+	return rowHeight
+}
+
+// drawTableCells draws one row with every cell at the same height so borders
+// and fills line up.
+func (e *Engine) drawTableCells(table *tableLayout, cells []tableCell, rowHeight float64, fill bool) {
+	border := ""
+	if table.Border {
+		border = "1"
+	}
+
+	x := e.flowLeftMargin()
+	y := e.pdf.GetY()
+	lineHeight := e.tableCellLineHeight(table)
+	textR, textG, textB := e.pdf.GetTextColor()
+	fillR, fillG, fillB := e.pdf.GetFillColor()
+	rowFill := fill
+
+	for _, cell := range cells {
+		align := cell.align
+		if align == "" {
+			align = "L"
+		}
+
+		e.pdf.SetTextColor(textR, textG, textB)
+		if cell.textColor != nil {
+			e.pdf.SetTextColor(cell.textColor.ToRGB())
+		}
+		e.pdf.SetFillColor(fillR, fillG, fillB)
+		fill = rowFill
+		if cell.fillColor != nil {
+			e.pdf.SetFillColor(cell.fillColor.ToRGB())
+			fill = true
+		}
+
+		e.pdf.SetXY(x, y)
+		if cell.graphic != "" {
+			e.pdf.CellFormat(cell.width, rowHeight, "", border, 0, "", fill, 0, "")
+			e.drawTableCellGraphic(cell, x, y, rowHeight)
+		} else if cell.wrap {
+			lines := e.splitTextLines(cell.text, cell.width)
+			e.pdf.CellFormat(cell.width, rowHeight, "", border, 0, "", fill, 0, "")
+
+			// Center the wrapped block vertically within the row.
+			lineY := y + (rowHeight-float64(len(lines))*lineHeight)/2
+			for _, line := range lines {
+				e.pdf.SetXY(x, lineY)
+				e.pdf.CellFormat(cell.width, lineHeight, line, "", 0, align, false, 0, "")
+				lineY += lineHeight
+			}
+		} else {
+			e.pdf.CellFormat(cell.width, rowHeight, cell.text, border, 0, align, fill, 0, "")
+		}
+		x += cell.width
+	}
+
+	e.pdf.SetTextColor(textR, textG, textB)
+	e.pdf.SetFillColor(fillR, fillG, fillB)
+	e.pdf.SetXY(e.flowLeftMargin(), y+rowHeight)
+}
+
+// drawTableCellGraphic draws the sparkline or progress bar of a cell inside
+// its margins, vertically centered in the row.
+func (e *Engine) drawTableCellGraphic(cell tableCell, x, y, rowHeight float64) {
+	margin := e.pdf.GetCellMargin()
+	width := cell.width - 2*margin
+	height := rowHeight - 3
+	if height > defaultTableRowHeight-3 {
+		height = defaultTableRowHeight - 3
+	}
+
+	switch cell.graphic {
+	case "sparkline":
+		e.drawSparkline(cell.values, x+margin, y+(rowHeight-height)/2, width, height, cell.color, 0)
+	case "progressBar":
+		barHeight := height * 0.75
+		e.drawProgressBar(cell.fraction, x+margin, y+(rowHeight-barHeight)/2, width, barHeight, cell.color, "")
+	}
+}
+
+func (e *Engine) applyTableCellStyle(table *tableLayout) {
+	if table.CellStyle != "" {
+		e.applyStyle(table.CellStyle)
+	}
+}
+
+// tableCellLineHeight returns the line height used for wrapped cells.
+func (e *Engine) tableCellLineHeight(table *tableLayout) float64 {
+	if style, ok := e.styles[table.CellStyle]; ok && style.LineHeight > 0 {
+		return style.LineHeight
+	}
+	return 6.0
+}
+
+// spanWidth returns the combined width of span columns starting at start.
+func (table *tableLayout) spanWidth(start, span int) float64 {
+	width := 0.0
+	for _, columnWidth := range table.widths[start : start+span] {
+		width += columnWidth
+	}
+	return width
+}
+
+// headerHeight returns the height of the header group rows and the column
+// header row together.
+func (table *tableLayout) headerHeight() float64 {
+	return float64(len(table.Columns.HeaderGroups)+1) * defaultTableRowHeight
+}
+
+// tableSpan clamps a colspan to at least one and at most the columns left.
+func tableSpan(colspan, remaining int) int {
+	if colspan < 1 {
+		return 1
+	}
+	if colspan > remaining {
+		return remaining
+	}
+	return colspan
+}
+
+// width returns the total width of the table.
+func (table *tableLayout) width() float64 {
+	width := 0.0
+	for _, columnWidth := range table.widths {
+		width += columnWidth
+	}
+	return width
+}
+
+// layoutTable resolves the column widths of a table. Absolute and percentage
+// widths are taken first, auto widths are measured from the header and the
+// cell text of every row, and fill columns share whatever space is left in
+// proportion to their weight. Sized columns that overflow the flow width are
+// scaled down to fit it.
+func (e *Engine) layoutTable(table *models.Table, rows []interface{}) *tableLayout {
+	columns := table.Columns.Columns
+	widths := make([]float64, len(columns))
+	weights := make([]float64, len(columns))
+	contentWidth := e.flowContentWidth()
+	remaining := contentWidth
+	totalWeight := 0.0
+
+	for idx, col := range columns {
+		spec := strings.TrimSpace(col.Width)
+		switch {
+		case spec == "" || strings.HasSuffix(spec, "*"):
+			weight := 1.0
+			if value, err := strconv.ParseFloat(strings.TrimSuffix(spec, "*"), 64); err == nil && value > 0 {
+				weight = value
+			}
+			weights[idx] = weight
+			totalWeight += weight
+			continue
+		case strings.EqualFold(spec, "auto"):
+			widths[idx] = math.Min(e.measureTableColumn(table, col, rows), contentWidth)
+		case strings.HasSuffix(spec, "%"):
+			if value, err := strconv.ParseFloat(strings.TrimSuffix(spec, "%"), 64); err == nil {
+				widths[idx] = contentWidth * value / 100
+			}
+		default:
+			if value, err := strconv.ParseFloat(spec, 64); err == nil {
+				widths[idx] = value
+			}
+		}
+		remaining -= widths[idx]
+	}
+
+	if remaining < 0 {
+		scale := contentWidth / (contentWidth - remaining)
+		for idx := range widths {
+			widths[idx] *= scale
+		}
+		remaining = 0
+	}
+
+	if totalWeight > 0 && remaining > 0 {
+		for idx, weight := range weights {
+			if weight > 0 {
+				widths[idx] = remaining * weight / totalWeight
+			}
+		}
+	}
+
+	return &tableLayout{Table: table, widths: widths}
+}
+
+// measureTableColumn returns the width needed to show the column header and
+// every cell on a single line, including the cell margins.
+func (e *Engine) measureTableColumn(table *models.Table, col models.Column, rows []interface{}) float64 {
+	if table.HeaderStyle != "" {
+		e.applyStyle(table.HeaderStyle)
+	}
+	width := e.pdf.GetStringWidth(col.Header)
+
+	if col.Type == "sparkline" || col.Type == "progressBar" {
+		return width + 2*e.pdf.GetCellMargin()
+	}
+
+	if table.CellStyle != "" {
+		e.applyStyle(table.CellStyle)
+	}
+	for idx, row := range rows {
+		for _, line := range strings.Split(e.tableCellText(col, row, idx), "\n") {
+			if lineWidth := e.pdf.GetStringWidth(line); lineWidth > width {
+				width = lineWidth
+			}
+		}
+	}
+
+	return width + 2*e.pdf.GetCellMargin()
+}
+
+// resolveTableRows resolves the table data source to its rows, applying the
+// table's filter, sort and limit with each row in scope as .row.
+func (e *Engine) resolveTableRows(table *models.Table) []interface{} {
+	return e.applyDataQuery(e.resolveDataItems(table.DataSource), table.DataQuery, "row")
+}
+
+// renderTableHeader draws the header group rows and the column header row at
+// the current Y position. Group cells span their colspan with a single merged
+// border; a column not covered by any group cell has its header stretched
+// down through the group rows.
+func (e *Engine) renderTableHeader(table *tableLayout) {
+	x := e.flowLeftMargin()
+	top := e.pdf.GetY()
+	y := top
+
+	// Apply header style
+	if table.HeaderStyle != "" {
+		e.applyStyle(table.HeaderStyle)
+	}
+
+	fill := table.HeaderStyle != ""
+	columns := table.Columns.Columns
+	covered := make([]bool, len(columns))
+	groupCovered := make([][]bool, len(table.Columns.HeaderGroups))
+	for row, group := range table.Columns.HeaderGroups {
+		groupCovered[row] = make([]bool, len(columns))
+		cellX := x
+		column := 0
+		for _, cell := range group.Cells {
+			if column >= len(columns) {
+				break
+			}
+
+			span := tableSpan(cell.Colspan, len(columns)-column)
+			width := table.spanWidth(column, span)
+			if cell.Header == "" {
+				// A cell without a header leaves its columns out of the row.
+				cellX += width
+				column += span
+				continue
+			}
+			align := cell.Align
+			if align == "" {
+				align = "C"
+			}
+
+			e.pdf.SetXY(cellX, y)
+			e.pdf.CellFormat(width, defaultTableRowHeight, cell.Header, "1", 0, align, fill, 0, "")
+			for idx := column; idx < column+span; idx++ {
+				covered[idx] = true
+				groupCovered[row][idx] = true
+			}
+			cellX += width
+			column += span
+		}
+		y += defaultTableRowHeight
+	}
+
+	// Columns grouped in another header row but not in this one get an
+	// empty cell, so the row has no gaps in its borders.
+	for row, rowCovered := range groupCovered {
+		for start := 0; start < len(columns); {
+			if rowCovered[start] || !covered[start] {
+				start++
+				continue
+			}
+			end := start
+			for end < len(columns) && !rowCovered[end] && covered[end] {
+				end++
+			}
+			e.pdf.SetXY(x+table.spanWidth(0, start), top+float64(row)*defaultTableRowHeight)
+			e.pdf.CellFormat(table.spanWidth(start, end-start), defaultTableRowHeight, "", "1", 0, "", fill, 0, "")
+			start = end
+		}
+	}
+
+	cellX := x
+	for idx, col := range columns {
+		cellY, height := y, defaultTableRowHeight
+		if !covered[idx] {
+			cellY, height = top, y+defaultTableRowHeight-top
+		}
+		e.pdf.SetXY(cellX, cellY)
+		e.pdf.CellFormat(table.widths[idx], height, col.Header, "1", 0, "C", fill, 0, "")
+		cellX += table.widths[idx]
+	}
+	e.pdf.SetXY(x, y+defaultTableRowHeight)
+}
+
+// renderTableContinuedCaption draws the optional caption shown above the
+// repeated header on continuation pages.
+func (e *Engine) renderTableContinuedCaption(table *tableLayout) {
+	caption := e.processTemplate(table.ContinuedCaption)
+	if caption == "" {
+		return
+	}
+
+	e.applyTableCellStyle(table)
+	e.pdf.SetX(e.flowLeftMargin())
+	e.pdf.CellFormat(e.flowContentWidth(), e.tableCellLineHeight(table), caption, "", 1, "L", false, 0, "")
+}
@@ -0,0 +1,511 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+func TestRenderTableRepeatsHeaderOnContinuationPages(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": tableTestRows(60)})
+	table := tableTestTable()
+	table.ContinuedCaption = "Transactions continued"
+
+	engine.renderTable(table)
+
+	if got := engine.pdf.PageNo(); got < 2 {
+		t.Fatalf("expected table to span multiple pages, got %d page(s)", got)
+	}
+
+	output := renderedPDF(t, engine)
+	if got := strings.Count(output, "(Reference)"); got != engine.pdf.PageNo() {
+		t.Fatalf("expected header to be drawn once per page (%d), got %d", engine.pdf.PageNo(), got)
+	}
+	if !strings.Contains(output, "Transactions continued") {
+		t.Fatalf("expected continued caption on continuation pages")
+	}
+}
+
+func TestRenderTableRepeatHeaderOptOut(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": tableTestRows(60)})
+	repeat := false
+	table := tableTestTable()
+	table.RepeatHeader = &repeat
+	table.ContinuedCaption = "Transactions continued"
+
+	engine.renderTable(table)
+
+	output := renderedPDF(t, engine)
+	if got := strings.Count(output, "(Reference)"); got != 1 {
+		t.Fatalf("expected header to be drawn once, got %d", got)
+	}
+	if strings.Contains(output, "Transactions continued") {
+		t.Fatalf("expected continued caption to be skipped when header repeat is disabled")
+	}
+}
+
+func TestRenderTableKeepsHeaderWithFirstRow(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": tableTestRows(1)})
+	engine.pdf.SetY(engine.pageBreakTrigger() - 10)
+
+	engine.renderTable(tableTestTable())
+
+	if got := engine.pdf.PageNo(); got != 2 {
+		t.Fatalf("expected header and first row to move to the next page, got page %d", got)
+	}
+}
+
+func TestRenderTableWrapsCellsAndGrowsRowHeight(t *testing.T) {
+	description := strings.Repeat("long description ", 12)
+	engine := newTestEngine(t, map[string]interface{}{"Rows": []interface{}{
+		map[string]interface{}{"ref": "TX-001", "description": description},
+	}})
+	table := tableTestTable()
+	table.Columns.Columns = append(table.Columns.Columns, models.Column{Header: "Description", Field: "description", Width: "50", Wrap: true})
+
+	startY := engine.pdf.GetY()
+	engine.renderTable(table)
+
+	lines := len(engine.splitTextLines(description, 50))
+	if lines < 3 {
+		t.Fatalf("expected description to wrap onto several lines, got %d", lines)
+	}
+	want := startY + defaultTableRowHeight + float64(lines)*5
+	if got := engine.pdf.GetY(); got != want {
+		t.Fatalf("expected row height to fit %d wrapped lines (Y %.2f), got %.2f", lines, want, got)
+	}
+}
+
+func TestRenderTableMovesWrappedRowToNextPage(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": []interface{}{
+		map[string]interface{}{"ref": "TX-001", "description": "short"},
+		map[string]interface{}{"ref": "TX-002", "description": strings.Repeat("wrapped text ", 20)},
+	}})
+	table := tableTestTable()
+	table.Columns.Columns = append(table.Columns.Columns, models.Column{Header: "Description", Field: "description", Width: "50", Wrap: true})
+	engine.pdf.SetY(engine.pageBreakTrigger() - 2*defaultTableRowHeight - 1)
+
+	engine.renderTable(table)
+
+	if got := engine.pdf.PageNo(); got != 2 {
+		t.Fatalf("expected tall wrapped row to move to page 2, got page %d", got)
+	}
+	_, top, _, _ := engine.pdf.GetMargins()
+	if got := engine.pdf.GetY(); got <= top+defaultTableRowHeight {
+		t.Fatalf("expected wrapped row below the repeated header on the new page, got Y %.2f", got)
+	}
+}
+
+func TestRenderTableSplitsRowTallerThanPage(t *testing.T) {
+	lines := make([]string, 80)
+	for idx := range lines {
+		lines[idx] = fmt.Sprintf("line%02d", idx)
+	}
+	engine := newTestEngine(t, map[string]interface{}{"Rows": []interface{}{
+		map[string]interface{}{"ref": "TX-001", "description": strings.Join(lines, "\n")},
+	}})
+	table := tableTestTable()
+	table.Columns.Columns = append(table.Columns.Columns, models.Column{Header: "Description", Field: "description", Width: "50", Wrap: true})
+
+	engine.renderTable(table)
+
+	// 80 lines of 5mm make a 400mm row, split over two 277mm pages that
+	// each start with the header row.
+	if got := engine.pdf.PageNo(); got != 2 {
+		t.Fatalf("expected the row to continue on page 2, got page %d", got)
+	}
+	_, top, _, _ := engine.pdf.GetMargins()
+	perPage := int((engine.pageContentHeight() - defaultTableRowHeight) / 5)
+	want := top + defaultTableRowHeight + float64(len(lines)-perPage)*5
+	if got := engine.pdf.GetY(); math.Abs(got-want) > 0.001 {
+		t.Fatalf("expected the rest of the row below the repeated header (Y %.2f), got %.2f", want, got)
+	}
+
+	output := renderedPDF(t, engine)
+	for _, line := range lines {
+		if got := strings.Count(output, "("+line+")"); got != 1 {
+			t.Fatalf("expected %s to be drawn once, got %d", line, got)
+		}
+	}
+	if got := strings.Count(output, "(TX-001)"); got != 1 {
+		t.Fatalf("expected unwrapped cells only in the first part, got %d", got)
+	}
+	if got := strings.Count(output, "(Reference)"); got != 2 {
+		t.Fatalf("expected the header on both pages, got %d", got)
+	}
+}
+
+func TestRenderTableGroupsRowsWithSubtotalsAndGrandTotal(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": []interface{}{
+		map[string]interface{}{"ref": "Alice", "dept": "Sales", "amount": 100.0},
+		map[string]interface{}{"ref": "Bob", "dept": "Support", "amount": 40.0},
+		map[string]interface{}{"ref": "Cara", "dept": "Sales", "amount": 25.5},
+	}})
+	table := tableTestTable()
+	table.GroupBy = &models.TableGroup{Field: "dept", Label: "Department {{.group}}"}
+	table.Footer = &models.TableFooter{Cells: []models.TableFooterCell{
+		{Label: "Grand total", SubtotalLabel: "Subtotal {{.group}}"},
+		{Aggregate: "sum"},
+	}}
+
+	engine.renderTable(table)
+
+	output := renderedPDF(t, engine)
+	for _, want := range []string{
+		"(Department Sales)", "(Department Support)",
+		"(Subtotal Sales)", "($125.50)",
+		"(Subtotal Support)", "($40)",
+		"(Grand total)", "($165.50)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %s in grouped table output", want)
+		}
+	}
+	if strings.Index(output, "(Cara)") > strings.Index(output, "(Subtotal Sales)") {
+		t.Fatalf("expected rows to be grouped by first appearance before the subtotal")
+	}
+}
+
+func TestAggregateTableRows(t *testing.T) {
+	rows := []interface{}{
+		map[string]interface{}{"amount": 10.0},
+		map[string]interface{}{"amount": "4"},
+		map[string]interface{}{"amount": "n/a"},
+		map[string]interface{}{"amount": -2},
+	}
+
+	tests := map[string]float64{"sum": 12, "avg": 4, "count": 4, "min": -2, "max": 10}
+	for function, want := range tests {
+		got, ok := aggregateTableRows(rows, "amount", function)
+		if !ok || got != want {
+			t.Fatalf("expected %s to be %.2f, got %.2f (ok=%v)", function, want, got, ok)
+		}
+	}
+
+	if _, ok := aggregateTableRows(rows, "amount", "median"); ok {
+		t.Fatalf("expected unknown aggregate function to be rejected")
+	}
+}
+
+func TestRenderTableEvaluatesColumnTemplatesPerRow(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Lines": []testLineItem{
+		{SKU: "SKU-RED", Qty: 3, Price: 2.5},
+		{SKU: "SKU-BLUE", Qty: 2, Price: 10},
+	}})
+
+	engine.renderTable(&models.Table{
+		DataSource: "{{.Lines}}",
+		Columns: models.Columns{Columns: []models.Column{
+			{Header: "Item", Width: "50", Template: "\n {{.rowNumber}}. {{.row.SKU}} \n"},
+			{Header: "Total", Width: "40", Template: "{{mul .row.Qty .row.Price | formatCurrency}}"},
+		}},
+	})
+
+	output := renderedPDF(t, engine)
+	for _, want := range []string{"(1. SKU-RED)", "(2. SKU-BLUE)", "($7.50)", "($20.00)"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %s in templated table output", want)
+		}
+	}
+}
+
+func TestRenderTableLeavesCellEmptyWhenTemplateFails(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Lines": []interface{}{
+		map[string]interface{}{"SKU": "SKU-RED", "Qty": 3, "Price": 2.5},
+		map[string]interface{}{"SKU": "SKU-BLUE", "Qty": 2},
+	}})
+
+	engine.renderTable(&models.Table{
+		DataSource: "{{.Lines}}",
+		Columns: models.Columns{Columns: []models.Column{
+			{Header: "Item", Width: "50", Field: "SKU"},
+			{Header: "Total", Width: "40", Template: "{{mul .row.Qty .row.Price | formatCurrency}}"},
+		}},
+	})
+
+	output := renderedPDF(t, engine)
+	if !strings.Contains(output, "($7.50)") || !strings.Contains(output, "(SKU-BLUE)") {
+		t.Fatalf("expected the complete row and the other cells in output")
+	}
+	if strings.Contains(output, "mul") {
+		t.Fatalf("expected the failing template to leave its cell empty")
+	}
+}
+
+func TestRenderTableAppliesConditionalRowAndCellStyles(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": []interface{}{
+		map[string]interface{}{"ref": "TX-001", "amount": 12.0, "overdue": false},
+		map[string]interface{}{"ref": "TX-002", "amount": -4.25, "overdue": true},
+	}})
+	engine.styles["negative"] = &models.Style{Name: "negative", TextColor: &models.RGBColor{R: 255}}
+	engine.styles["overdue"] = &models.Style{Name: "overdue", FillColor: &models.RGBColor{R: 255, G: 191}}
+
+	table := tableTestTable()
+	table.RowStyles = []models.TableRowStyle{{Condition: "{{.row.overdue}}", Style: "overdue"}}
+	table.Columns.Columns[1].CellStyle = "negative"
+	table.Columns.Columns[1].CellStyleCondition = "{{if lt .row.amount 0.0}}true{{end}}"
+
+	engine.renderTable(table)
+
+	output := renderedPDF(t, engine)
+	if got := strings.Count(output, "1.000 0.000 0.000 rg"); got != 1 {
+		t.Fatalf("expected exactly one negative cell in red, got %d", got)
+	}
+	if !strings.Contains(output, "1.000 0.749 0.000 rg") {
+		t.Fatalf("expected the overdue row fill color to be applied")
+	}
+	if !strings.Contains(output, "(-$4.25)") {
+		t.Fatalf("expected negative amount to be formatted")
+	}
+}
+
+func TestLayoutTableResolvesFlexibleColumnWidths(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	rows := []interface{}{
+		map[string]interface{}{"ref": "TX-0000000001", "amount": 12.5},
+	}
+	table := &models.Table{Columns: models.Columns{Columns: []models.Column{
+		{Header: "Ref", Field: "ref", Width: "auto"},
+		{Header: "Share", Width: "25%"},
+		{Header: "Amount", Field: "amount", Width: "30"},
+		{Header: "Notes", Width: "*"},
+		{Header: "Extra", Width: "2*"},
+	}}}
+
+	layout := engine.layoutTable(table, rows)
+
+	contentWidth := engine.flowContentWidth()
+	autoWidth := engine.pdf.GetStringWidth("TX-0000000001") + 2*engine.pdf.GetCellMargin()
+	if got := layout.widths[0]; math.Abs(got-autoWidth) > 0.001 {
+		t.Fatalf("expected auto column to fit its widest cell (%.2f), got %.2f", autoWidth, got)
+	}
+	if got := layout.widths[1]; math.Abs(got-contentWidth/4) > 0.001 {
+		t.Fatalf("expected percentage column to be a quarter of the flow width, got %.2f", got)
+	}
+	if got := layout.widths[2]; got != 30 {
+		t.Fatalf("expected absolute column width to be kept, got %.2f", got)
+	}
+	if got, want := layout.widths[4], 2*layout.widths[3]; math.Abs(got-want) > 0.001 {
+		t.Fatalf("expected weighted fill column to take twice the share (%.2f), got %.2f", want, got)
+	}
+	if got := layout.width(); math.Abs(got-contentWidth) > 0.001 {
+		t.Fatalf("expected fill columns to complete the flow width %.2f, got %.2f", contentWidth, got)
+	}
+}
+
+func TestLayoutTableKeepsWithinFlowWidth(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	contentWidth := engine.flowContentWidth()
+
+	table := &models.Table{Columns: models.Columns{Columns: []models.Column{
+		{Header: "Share", Width: "80%"},
+		{Header: "Notes", Width: "*"},
+	}}}
+	layout := engine.layoutTable(table, nil)
+	if got, want := layout.widths[0], contentWidth*0.8; math.Abs(got-want) > 0.001 {
+		t.Fatalf("expected the percentage column to keep %.2f next to a fill column, got %.2f", want, got)
+	}
+	if got, want := layout.widths[1], contentWidth*0.2; math.Abs(got-want) > 0.001 {
+		t.Fatalf("expected the fill column to take the remaining %.2f, got %.2f", want, got)
+	}
+
+	table = &models.Table{Columns: models.Columns{Columns: []models.Column{
+		{Header: "Wide", Width: "150"},
+		{Header: "Share", Width: "40%"},
+		{Header: "Notes", Width: "*"},
+	}}}
+	layout = engine.layoutTable(table, nil)
+	if got := layout.width(); math.Abs(got-contentWidth) > 0.001 {
+		t.Fatalf("expected the table to fit the flow width %.2f, got %.2f", contentWidth, got)
+	}
+	if got := layout.widths[2]; got != 0 {
+		t.Fatalf("expected no space left for the fill column, got %.2f", got)
+	}
+	if got, want := layout.widths[0]/layout.widths[1], 150/(contentWidth*0.4); math.Abs(got-want) > 0.001 {
+		t.Fatalf("expected overflowing sized columns to shrink proportionally, got ratio %.3f", got)
+	}
+
+	rows := []interface{}{map[string]interface{}{"text": strings.Repeat("very long cell text ", 20)}}
+	table = &models.Table{Columns: models.Columns{Columns: []models.Column{
+		{Header: "Text", Field: "text", Width: "auto"},
+		{Header: "Fixed", Width: "30"},
+	}}}
+	layout = engine.layoutTable(table, rows)
+	if got := layout.width(); got > contentWidth+0.001 {
+		t.Fatalf("expected auto columns to be capped to the flow width %.2f, got %.2f", contentWidth, got)
+	}
+}
+
+func TestLayoutTableUsesCurrentFlowBounds(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	table := &models.Table{Columns: models.Columns{Columns: []models.Column{
+		{Header: "Half", Width: "50%"},
+		{Header: "Rest", Width: "*"},
+	}}}
+
+	var layout *tableLayout
+	_ = engine.withFlowBounds(20, 10, func() error {
+		layout = engine.layoutTable(table, nil)
+		return nil
+	})
+
+	if got, want := layout.width(), engine.flowContentWidth()-30; math.Abs(got-want) > 0.001 {
+		t.Fatalf("expected table to fill the padded flow width %.2f, got %.2f", want, got)
+	}
+}
+
+func TestRenderTableDrawsHeaderGroupsAboveColumnHeaders(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": tableTestRows(60)})
+	table := tableTestTable()
+	table.Columns.HeaderGroups = []models.HeaderGroup{{Cells: []models.HeaderGroupCell{
+		{Header: "Booked amounts", Colspan: 2},
+	}}}
+
+	engine.renderTable(table)
+
+	output := renderedPDF(t, engine)
+	pages := engine.pdf.PageNo()
+	if got := strings.Count(output, "(Booked amounts)"); got != pages {
+		t.Fatalf("expected header group once per page (%d), got %d", pages, got)
+	}
+	if got := strings.Count(output, "(Reference)"); got != pages {
+		t.Fatalf("expected column headers once per page (%d), got %d", pages, got)
+	}
+}
+
+func TestRenderTableHeaderGroupsStackRows(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": []interface{}{}})
+	table := tableTestTable()
+	table.Columns.HeaderGroups = []models.HeaderGroup{
+		{Cells: []models.HeaderGroupCell{{Header: "Ledger", Colspan: 2}}},
+		{Cells: []models.HeaderGroupCell{{Header: ""}, {Header: "Posted"}}},
+	}
+
+	startY := engine.pdf.GetY()
+	engine.renderTable(table)
+
+	if got, want := engine.pdf.GetY(), startY+3*defaultTableRowHeight; got != want {
+		t.Fatalf("expected two group rows above the column headers (Y %.2f), got %.2f", want, got)
+	}
+}
+
+func TestRenderTableHeaderGroupsSkipAndFillColumns(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Rows": []interface{}{}})
+	table := tableTestTable()
+	table.Columns.Columns = append(table.Columns.Columns, models.Column{Header: "Tax", Field: "tax", Width: "20"})
+	table.Columns.HeaderGroups = []models.HeaderGroup{
+		{Cells: []models.HeaderGroupCell{{}, {Header: "Totals"}}},
+		{Cells: []models.HeaderGroupCell{{}, {}, {Header: "Taxes"}}},
+	}
+
+	engine.renderTable(table)
+
+	output := renderedPDF(t, engine)
+	rects := pdfRects(output)
+	row := defaultTableRowHeight
+	// Reference is skipped by both group rows, so its header spans all
+	// three header rows.
+	if !hasRect(rects, 10, 10, 60, 3*row) || strings.Count(output, "(Reference)") != 1 {
+		t.Fatalf("expected one Reference header stretched through the group rows, got %v", rects)
+	}
+	// Each group row covers a column the other leaves out, which gets an
+	// empty cell instead of a gap.
+	if !hasRect(rects, 110, 10, 20, row) {
+		t.Fatalf("expected an empty cell above Taxes in the first header row, got %v", rects)
+	}
+	if !hasRect(rects, 70, 10+row, 40, row) {
+		t.Fatalf("expected an empty cell below Totals in the second header row, got %v", rects)
+	}
+}
+
+// pdfRects returns the rectangles drawn on an A4 page of the PDF output as
+// x, y, width, and height in millimetres from the top left corner.
+func pdfRects(output string) [][4]float64 {
+	const pointsPerMM = 72 / 25.4
+	var rects [][4]float64
+	for _, match := range regexp.MustCompile(`(-?[0-9.]+) (-?[0-9.]+) (-?[0-9.]+) (-?[0-9.]+) re [SfB]`).FindAllStringSubmatch(output, -1) {
+		var values [4]float64
+		for idx := range values {
+			values[idx], _ = strconv.ParseFloat(match[idx+1], 64)
+		}
+		rects = append(rects, [4]float64{
+			values[0] / pointsPerMM,
+			297 - values[1]/pointsPerMM,
+			values[2] / pointsPerMM,
+			-values[3] / pointsPerMM,
+		})
+	}
+	return rects
+}
+
+// hasRect reports whether rects holds a rectangle at x, y of the given size,
+// to within the rounding of the PDF output.
+func hasRect(rects [][4]float64, x, y, width, height float64) bool {
+	for _, rect := range rects {
+		if math.Abs(rect[0]-x) < 0.01 && math.Abs(rect[1]-y) < 0.01 && math.Abs(rect[2]-width) < 0.01 && math.Abs(rect[3]-height) < 0.01 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTableSummaryCellsSpanColumns(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	table := tableTestTable()
+	table.Columns.Columns = append(table.Columns.Columns,
+		models.Column{Header: "Tax", Field: "tax", Width: "20", Format: "number"},
+		models.Column{Header: "Notes", Width: "30"},
+	)
+	table.Footer = &models.TableFooter{Cells: []models.TableFooterCell{
+		{Label: "Total", Colspan: 2},
+		{Aggregate: "sum"},
+	}}
+	rows := []interface{}{
+		map[string]interface{}{"amount": 10.0, "tax": 1.5},
+		map[string]interface{}{"amount": 5.0, "tax": 0.5},
+	}
+
+	cells := engine.tableSummaryCells(engine.layoutTable(table, rows), tableRowGroup{rows: rows}, false)
+
+	if len(cells) != 3 {
+		t.Fatalf("expected a merged label, the tax total and an empty notes cell, got %d cells", len(cells))
+	}
+	if cells[0].text != "Total" || cells[0].width != 100 {
+		t.Fatalf("expected label to span reference and amount (100), got %q width %.2f", cells[0].text, cells[0].width)
+	}
+	if cells[1].text != "2" || cells[1].width != 20 {
+		t.Fatalf("expected tax sum under the tax column, got %q width %.2f", cells[1].text, cells[1].width)
+	}
+	if cells[2].text != "" || cells[2].width != 30 {
+		t.Fatalf("expected trailing column to stay empty, got %q width %.2f", cells[2].text, cells[2].width)
+	}
+}
+
+func tableTestTable() *models.Table {
+	return &models.Table{
+		DataSource:  "{{.Rows}}",
+		HeaderStyle: "body",
+		CellStyle:   "body",
+		Border:      true,
+		Columns: models.Columns{Columns: []models.Column{
+			{Header: "Reference", Field: "ref", Width: "60"},
+			{Header: "Amount", Field: "amount", Width: "40", Align: "R", Format: "currency"},
+		}},
+	}
+}
+
+func tableTestRows(count int) []interface{} {
+	rows := make([]interface{}, count)
+	for i := range rows {
+		rows[i] = map[string]interface{}{
+			"ref":    fmt.Sprintf("TX-%03d", i+1),
+			"amount": float64(i) * 1.5,
+		}
+	}
+	return rows
+}
@@ -0,0 +1,134 @@
+// Package engine provides table of contents functionality.
+package engine
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+// tocEntry is a table of contents line recorded during layout.
+type tocEntry struct {
+	title string
+	level int
+	page  int
+}
+
+// tocTitle returns the entry title for a tocEntry attribute value, using
+// fallback when the value is "true", and false when there is no entry.
+func (e *Engine) tocTitle(value, fallback string) (string, bool) {
+	if !e.shouldRenderCondition(value) {
+		return "", false
+	}
+
+	title := strings.TrimSpace(e.processTemplate(value))
+	if strings.EqualFold(title, "true") {
+		title = e.processTemplate(fallback)
+	}
+	title = strings.Join(strings.Fields(title), " ")
+	return title, title != ""
+}
+
+// addTocEntry records a table of contents entry at the given position and
+// points the entry's link there.
+func (e *Engine) addTocEntry(title string, level, page int, y float64) {
+	if level < 1 {
+		level = 1
+	}
+	e.pdf.SetLink(e.tocLink(len(e.tocCollected)), y, page)
+	e.tocCollected = append(e.tocCollected, tocEntry{title: title, level: level, page: page})
+}
+
+// tocLink returns the link ID of the entry with the given index, registering
+// it on first use because the contents are usually drawn before the entry.
+func (e *Engine) tocLink(index int) int {
+	if link, ok := e.tocLinks[index]; ok {
+		return link
+	}
+	link := e.pdf.AddLink()
+	e.tocLinks[index] = link
+	return link
+}
+
+func tocEntriesEqual(a, b []tocEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// renderToc draws the entries recorded by the previous layout pass. Each line
+// links to its entry.
+func (e *Engine) renderToc(toc *models.Toc) {
+	e.tocRendered = true
+
+	if toc.Style != "" {
+		e.applyStyle(toc.Style)
+	}
+
+	lineHeight := 6.0
+	if style, ok := e.styles[toc.Style]; ok && style.LineHeight > 0 {
+		lineHeight = style.LineHeight
+	}
+
+	indent := toc.Indent
+	if indent == 0 {
+		indent = 5
+	}
+
+	leader := toc.Leader
+	if leader == "" {
+		leader = "."
+	}
+
+	pageWidth, _ := e.pdf.GetPageSize()
+	right := pageWidth - e.flowRightMargin()
+	cellMargin := e.pdf.GetCellMargin()
+	leaderWidth := e.pdf.GetStringWidth(leader)
+
+	for idx, entry := range e.tocEntries {
+		if toc.MaxLevel > 0 && entry.level > toc.MaxLevel {
+			continue
+		}
+
+		if !e.fitsOnPage(lineHeight) {
+			e.pdf.AddPage()
+		}
+
+		left := e.flowLeftMargin() + float64(entry.level-1)*indent
+		y := e.pdf.GetY()
+		page := strconv.Itoa(entry.page)
+		titleWidth := e.pdf.GetStringWidth(entry.title) + 2*cellMargin
+		pageNumberWidth := e.pdf.GetStringWidth(page) + 2*cellMargin
+
+		e.pdf.SetXY(left, y)
+		e.pdf.CellFormat(titleWidth, lineHeight, entry.title, "", 0, "L", false, 0, "")
+
+		// Leaders end flush against the page number so they line up.
+		if leaderWidth > 0 {
+			gap := right - pageNumberWidth - (left + titleWidth)
+			if count := int(math.Floor(gap / leaderWidth)); count > 0 {
+				width := float64(count) * leaderWidth
+				e.pdf.SetXY(right-pageNumberWidth-width, y)
+				e.pdf.CellFormat(width, lineHeight, strings.Repeat(leader, count), "", 0, "L", false, 0, "")
+			}
+		}
+
+		e.pdf.SetXY(right-pageNumberWidth, y)
+		e.pdf.CellFormat(pageNumberWidth, lineHeight, page, "", 0, "R", false, 0, "")
+
+		e.pdf.Link(left, y, right-left, lineHeight, e.tocLink(idx))
+		e.pdf.SetY(y + lineHeight)
+	}
+
+	if toc.SpacingAfter > 0 {
+		e.pdf.Ln(toc.SpacingAfter)
+	}
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
+
+func TestRenderCollectsTocEntriesAcrossPasses(t *testing.T) {
+	engine := newTestEngine(t, map[string]interface{}{"Regions": []interface{}{"North", "South"}})
+	engine.report.Sections.Sections = []models.Section{
+		{
+			Name:     "contents",
+			Elements: []models.SectionElement{{Type: "toc", Toc: &models.Toc{}}},
+		},
+		{
+			Name:            "Introduction",
+			TocEntry:        "true",
+			PageBreakBefore: true,
+			Elements: []models.SectionElement{
+				{Type: "text", Text: &models.Text{Content: "Background", TocEntry: "true", TocLevel: 2}},
+			},
+		},
+		{
+			Name:            "regions",
+			Loop:            "{{.Regions}}",
+			TocEntry:        "Region {{.item}}",
+			PageBreakBefore: true,
+			Elements: []models.SectionElement{
+				{Type: "text", Text: &models.Text{Content: "{{.item}}", TocEntry: "false"}},
+			},
+		},
+	}
+
+	if err := engine.render(); err != nil {
+		t.Fatalf("render returned error: %v", err)
+	}
+
+	want := []tocEntry{
+		{title: "Introduction", level: 1, page: 2},
+		{title: "Background", level: 2, page: 2},
+		{title: "Region North", level: 1, page: 3},
+		{title: "Region South", level: 1, page: 3},
+	}
+	if !reflect.DeepEqual(engine.tocEntries, want) {
+		t.Fatalf("expected toc entries %#v, got %#v", want, engine.tocEntries)
+	}
+	if !reflect.DeepEqual(engine.tocCollected, want) {
+		t.Fatalf("expected final pass to match, got %#v", engine.tocCollected)
+	}
+}
+
+func TestRenderTocDrawsEntriesWithLeaders(t *testing.T) {
+	engine := newTestEngine(t, nil)
+	engine.tocEntries = []tocEntry{
+		{title: "Summary", level: 1, page: 2},
+		{title: "Details", level: 2, page: 14},
+		{title: "Appendix", level: 3, page: 20},
+	}
+
+	engine.renderToc(&models.Toc{Leader: ".", MaxLevel: 2})
+
+	output := renderedPDF(t, engine)
+	for _, want := range []string{"(Summary)", "(Details)", "(2)", "(14)", "(....."} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %s in output", want)
+		}
+	}
+	if strings.Contains(output, "(Appendix)") {
+		t.Fatalf("expected entries below maxLevel to be skipped")
+	}
+	if textPosition(t, output, "Details") <= textPosition(t, output, "Summary") {
+		t.Fatalf("expected level 2 entry to be indented")
+	}
+	if !strings.Contains(output, "/Dest [") {
+		t.Fatalf("expected toc lines to link to their entries")
+	}
+}
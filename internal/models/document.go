@@ -4,6 +4,7 @@ package models
 import (
 	"encoding/xml"
 	"fmt"
+	"strconv"
 )
 
 // Document represents the document configuration.
@@ -31,20 +32,22 @@ type CustomSize struct {
 
 // Header represents the page header configuration.
 type Header struct {
-	Enabled bool    `xml:"enabled,attr"`
-	Height  float64 `xml:"height,attr"`
-	Texts   []Text  `xml:"text"`
-	Images  []Image `xml:"image"`
-	Lines   []Line  `xml:"line"`
+	Enabled bool     `xml:"enabled,attr"`
+	Height  float64  `xml:"height,attr"`
+	Texts   []Text   `xml:"text"`
+	Images  []Image  `xml:"image"`
+	Lines   []Line   `xml:"line"`
+	QRCodes []QRCode `xml:"qrcode"`
 }
 
 // Footer represents the page footer configuration.
 type Footer struct {
-	Enabled bool    `xml:"enabled,attr"`
-	Height  float64 `xml:"height,attr"`
-	Texts   []Text  `xml:"text"`
-	Images  []Image `xml:"image"`
-	Lines   []Line  `xml:"line"`
+	Enabled bool     `xml:"enabled,attr"`
+	Height  float64  `xml:"height,attr"`
+	Texts   []Text   `xml:"text"`
+	Images  []Image  `xml:"image"`
+	Lines   []Line   `xml:"line"`
+	QRCodes []QRCode `xml:"qrcode"`
 }
 
 // Sections contains all report sections.
@@ -58,12 +61,20 @@ type SectionElement struct {
 	Text      *Text
 	Image     *Image
 	Table     *Table
+	Pivot     *Pivot
+	Chart     *Chart
+	Sparkline *Sparkline
+	Progress  *ProgressBar
+	QRCode    *QRCode
+	Barcode   *Barcode
+	Toc       *Toc
 	List      *List
 	KVList    *KeyValueList
 	Line      *Line
 	Rectangle *Rectangle
 	Row       *Row
 	RowGrid   *RowGrid
+	Box       *Box
 	Spacer    *Spacer
 	PageBreak *PageBreak
 }
@@ -77,6 +88,22 @@ type Section struct {
 	Loop            string  `xml:"loop,attr"`
 	LoopVariable    string  `xml:"loopVariable,attr"`
 	PaddingLeft     float64 `xml:"paddingLeft,attr"`
+	Anchor          string  `xml:"anchor,attr"`
+	// TocEntry adds the section to the table of contents: "true" uses the
+	// name as the entry title, any other truthy value is the title.
+	TocEntry string `xml:"tocEntry,attr"`
+	TocLevel int    `xml:"tocLevel,attr"`
+	// Title is the display title for bookmarks and contents entries,
+	// defaulting to Name. It is evaluated per item in looped sections.
+	Title string `xml:"title,attr"`
+	// Bookmark adds the section to the PDF outline (default: true).
+	Bookmark *bool `xml:"bookmark,attr"`
+	// KeepTogether moves the section, or each item of a looped section, to
+	// a new page when it does not fit on the current one.
+	KeepTogether bool `xml:"keepTogether,attr"`
+
+	// DataQuery filters, sorts and limits the loop items.
+	DataQuery
 
 	// Elements in document order
 	Elements []SectionElement
@@ -108,6 +135,22 @@ func (s *Section) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 			s.PageBreakAfter = attr.Value == "true"
 		case "condition":
 			s.Condition = attr.Value
+		case "anchor":
+			s.Anchor = attr.Value
+		case "keepTogether":
+			s.KeepTogether = attr.Value == "true"
+		case "title":
+			s.Title = attr.Value
+		case "bookmark":
+			if value, err := strconv.ParseBool(attr.Value); err == nil {
+				s.Bookmark = &value
+			}
+		case "tocEntry":
+			s.TocEntry = attr.Value
+		case "tocLevel":
+			if _, err := fmt.Sscanf(attr.Value, "%d", &s.TocLevel); err != nil {
+				s.TocLevel = 0
+			}
 		case "loop":
 			s.Loop = attr.Value
 		case "loopVariable":
@@ -116,6 +159,16 @@ func (s *Section) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 			if _, err := fmt.Sscanf(attr.Value, "%f", &s.PaddingLeft); err != nil {
 				s.PaddingLeft = 0
 			}
+		case "sortBy":
+			s.SortBy = attr.Value
+		case "sortOrder":
+			s.SortOrder = attr.Value
+		case "filter":
+			s.Filter = attr.Value
+		case "limit":
+			if _, err := fmt.Sscanf(attr.Value, "%d", &s.Limit); err != nil {
+				s.Limit = 0
+			}
 		}
 	}
 
@@ -203,6 +256,10 @@ func (r *Row) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		switch attr.Name.Local {
 		case "condition":
 			r.Condition = attr.Value
+		case "anchor":
+			r.Anchor = attr.Value
+		case "keepTogether":
+			r.KeepTogether = attr.Value == "true"
 		case "spacingAfter":
 			if _, err := fmt.Sscanf(attr.Value, "%f", &r.SpacingAfter); err != nil {
 				r.SpacingAfter = 0
@@ -218,23 +275,11 @@ func (r *Row) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 
 		switch t := token.(type) {
 		case xml.StartElement:
-			var elem SectionElement
-			switch t.Name.Local {
-			case "text":
-				var text Text
-				if err := d.DecodeElement(&text, &t); err != nil {
-					return err
-				}
-				elem.Type = "text"
-				elem.Text = &text
-			case "image":
-				var img Image
-				if err := d.DecodeElement(&img, &t); err != nil {
-					return err
-				}
-				elem.Type = "image"
-				elem.Image = &img
-			default:
+			elem, ok, err := decodeSectionElement(d, &t)
+			if err != nil {
+				return err
+			}
+			if !ok {
 				if err := d.Skip(); err != nil {
 					return err
 				}
@@ -255,6 +300,10 @@ func (r *RowGrid) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		switch attr.Name.Local {
 		case "condition":
 			r.Condition = attr.Value
+		case "anchor":
+			r.Anchor = attr.Value
+		case "keepTogether":
+			r.KeepTogether = attr.Value == "true"
 		case "spacingAfter":
 			if _, err := fmt.Sscanf(attr.Value, "%f", &r.SpacingAfter); err != nil {
 				r.SpacingAfter = 0
@@ -263,6 +312,20 @@ func (r *RowGrid) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 			if _, err := fmt.Sscanf(attr.Value, "%d", &r.Columns); err != nil {
 				r.Columns = 0
 			}
+		case "widths":
+			r.Widths = attr.Value
+		case "gap":
+			if _, err := fmt.Sscanf(attr.Value, "%f", &r.Gap); err != nil {
+				r.Gap = 0
+			}
+		case "divider":
+			r.Divider = attr.Value == "true"
+		case "dividerColor":
+			r.DividerColor = attr.Value
+		case "dividerWidth":
+			if _, err := fmt.Sscanf(attr.Value, "%f", &r.DividerWidth); err != nil {
+				r.DividerWidth = 0
+			}
 		}
 	}
 
@@ -296,6 +359,19 @@ func (r *RowGrid) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 
 // UnmarshalXML implements custom XML unmarshaling for rowgrid columns.
 func (c *RowGridColumn) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "width":
+			if _, err := fmt.Sscanf(attr.Value, "%f", &c.Width); err != nil {
+				c.Width = 0
+			}
+		case "weight":
+			if _, err := fmt.Sscanf(attr.Value, "%f", &c.Weight); err != nil {
+				c.Weight = 0
+			}
+		}
+	}
+
 	for {
 		token, err := d.Token()
 		if err != nil {
@@ -323,6 +399,78 @@ func (c *RowGridColumn) UnmarshalXML(d *xml.Decoder, start xml.StartElement) err
 	}
 }
 
+// UnmarshalXML implements custom XML unmarshaling for boxes. The fillColor
+// and borderColor children set the colors; other children are the content.
+func (b *Box) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "condition":
+			b.Condition = attr.Value
+		case "anchor":
+			b.Anchor = attr.Value
+		case "keepTogether":
+			b.KeepTogether = attr.Value == "true"
+		case "spacingAfter":
+			if _, err := fmt.Sscanf(attr.Value, "%f", &b.SpacingAfter); err != nil {
+				b.SpacingAfter = 0
+			}
+		case "padding":
+			if _, err := fmt.Sscanf(attr.Value, "%f", &b.Padding); err != nil {
+				b.Padding = 0
+			}
+		case "radius":
+			if _, err := fmt.Sscanf(attr.Value, "%f", &b.Radius); err != nil {
+				b.Radius = 0
+			}
+		case "borderWidth":
+			if _, err := fmt.Sscanf(attr.Value, "%f", &b.BorderWidth); err != nil {
+				b.BorderWidth = 0
+			}
+		}
+	}
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "fillColor":
+				b.FillColor = &RGBColor{}
+				if err := d.DecodeElement(b.FillColor, &t); err != nil {
+					return err
+				}
+				continue
+			case "borderColor":
+				b.BorderColor = &RGBColor{}
+				if err := d.DecodeElement(b.BorderColor, &t); err != nil {
+					return err
+				}
+				continue
+			}
+
+			elem, ok, err := decodeSectionElement(d, &t)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			b.Elements = append(b.Elements, elem)
+		case xml.EndElement:
+			if t.Name == start.Name {
+				return nil
+			}
+		}
+	}
+}
+
 func decodeSectionElement(d *xml.Decoder, start *xml.StartElement) (SectionElement, bool, error) {
 	var elem SectionElement
 
@@ -348,6 +496,55 @@ func decodeSectionElement(d *xml.Decoder, start *xml.StartElement) (SectionEleme
 		}
 		elem.Type = "table"
 		elem.Table = &table
+	case "pivot":
+		var pivot Pivot
+		if err := d.DecodeElement(&pivot, start); err != nil {
+			return SectionElement{}, false, err
+		}
+		elem.Type = "pivot"
+		elem.Pivot = &pivot
+	case "chart":
+		var chart Chart
+		if err := d.DecodeElement(&chart, start); err != nil {
+			return SectionElement{}, false, err
+		}
+		elem.Type = "chart"
+		elem.Chart = &chart
+	case "sparkline":
+		var sparkline Sparkline
+		if err := d.DecodeElement(&sparkline, start); err != nil {
+			return SectionElement{}, false, err
+		}
+		elem.Type = "sparkline"
+		elem.Sparkline = &sparkline
+	case "progressBar":
+		var progress ProgressBar
+		if err := d.DecodeElement(&progress, start); err != nil {
+			return SectionElement{}, false, err
+		}
+		elem.Type = "progressBar"
+		elem.Progress = &progress
+	case "qrcode":
+		var qr QRCode
+		if err := d.DecodeElement(&qr, start); err != nil {
+			return SectionElement{}, false, err
+		}
+		elem.Type = "qrcode"
+		elem.QRCode = &qr
+	case "barcode":
+		var barcode Barcode
+		if err := d.DecodeElement(&barcode, start); err != nil {
+			return SectionElement{}, false, err
+		}
+		elem.Type = "barcode"
+		elem.Barcode = &barcode
+	case "toc":
+		var toc Toc
+		if err := d.DecodeElement(&toc, start); err != nil {
+			return SectionElement{}, false, err
+		}
+		elem.Type = "toc"
+		elem.Toc = &toc
 	case "list":
 		var list List
 		if err := d.DecodeElement(&list, start); err != nil {
@@ -390,6 +587,13 @@ func decodeSectionElement(d *xml.Decoder, start *xml.StartElement) (SectionEleme
 		}
 		elem.Type = "rowgrid"
 		elem.RowGrid = &rowGrid
+	case "box":
+		var box Box
+		if err := d.DecodeElement(&box, start); err != nil {
+			return SectionElement{}, false, err
+		}
+		elem.Type = "box"
+		elem.Box = &box
 	case "spacer":
 		var spacer Spacer
 		if err := d.DecodeElement(&spacer, start); err != nil {
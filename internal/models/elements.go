@@ -11,6 +11,20 @@ type Element interface {
 type BaseElement struct {
 	Condition    string  `xml:"condition,attr"`
 	SpacingAfter float64 `xml:"spacingAfter,attr"`
+	// Anchor names a link destination at the start of the element.
+	Anchor string `xml:"anchor,attr"`
+	// KeepTogether moves the element to a new page when it does not fit on
+	// the current one.
+	KeepTogether bool `xml:"keepTogether,attr"`
+}
+
+// DataQuery filters, sorts and limits the items of a data source before they
+// are rendered. Filter is a condition template evaluated once per item.
+type DataQuery struct {
+	SortBy    string `xml:"sortBy,attr"`
+	SortOrder string `xml:"sortOrder,attr"`
+	Filter    string `xml:"filter,attr"`
+	Limit     int    `xml:"limit,attr"`
 }
 
 // Text represents a text element.
@@ -23,6 +37,22 @@ type Text struct {
 	Width   float64 `xml:"width,attr"`
 	Align   string  `xml:"align,attr"`
 	Wrap    bool    `xml:"wrap,attr"`
+	// Href is an external URL, or #name to link to an anchor.
+	Href string `xml:"href,attr"`
+	// TocEntry adds the text to the table of contents: "true" uses the
+	// content as the entry title, any other truthy value is the title.
+	TocEntry string `xml:"tocEntry,attr"`
+	TocLevel int    `xml:"tocLevel,attr"`
+	// KeepWithNext keeps the text on the same page as the start of the
+	// next element.
+	KeepWithNext bool `xml:"keepWithNext,attr"`
+	// MinLinesBefore and MinLinesAfter override the style's orphan and
+	// widow settings.
+	MinLinesBefore int `xml:"minLinesBefore,attr"`
+	MinLinesAfter  int `xml:"minLinesAfter,attr"`
+
+	// Runs holds the content split by inline markup, or nil for plain text.
+	Runs []TextRun `xml:"-"`
 }
 
 // GetType returns the element type.
@@ -40,6 +70,8 @@ type Image struct {
 	Width  float64 `xml:"width,attr"`
 	Height float64 `xml:"height,attr"`
 	Align  string  `xml:"align,attr"`
+	// Href is an external URL, or #name to link to an anchor.
+	Href string `xml:"href,attr"`
 }
 
 // GetType returns the element type.
@@ -51,12 +83,18 @@ func (i Image) GetCondition() string { return i.Condition }
 // Table represents a table element.
 type Table struct {
 	BaseElement
-	DataSource        string    `xml:"dataSource,attr"`
-	HeaderStyle       string    `xml:"headerStyle,attr"`
-	CellStyle         string    `xml:"cellStyle,attr"`
-	Border            bool      `xml:"border,attr"`
-	AlternateRowColor *RGBColor `xml:"alternateRowColor"`
-	Columns           Columns   `xml:"columns"`
+	DataQuery
+	DataSource        string          `xml:"dataSource,attr"`
+	HeaderStyle       string          `xml:"headerStyle,attr"`
+	CellStyle         string          `xml:"cellStyle,attr"`
+	Border            bool            `xml:"border,attr"`
+	RepeatHeader      *bool           `xml:"repeatHeader,attr"`
+	ContinuedCaption  string          `xml:"continuedCaption,attr"`
+	AlternateRowColor *RGBColor       `xml:"alternateRowColor"`
+	Columns           Columns         `xml:"columns"`
+	RowStyles         []TableRowStyle `xml:"rowStyle"`
+	GroupBy           *TableGroup     `xml:"groupBy"`
+	Footer            *TableFooter    `xml:"footer"`
 }
 
 // GetType returns the element type.
@@ -65,23 +103,270 @@ func (t Table) GetType() string { return "table" }
 // GetCondition returns the condition for rendering.
 func (t Table) GetCondition() string { return t.Condition }
 
-// Columns contains table column definitions.
+// Columns contains table column definitions. Header groups add header rows
+// above the column headers, in document order.
 type Columns struct {
-	Columns []Column `xml:"column"`
+	HeaderGroups []HeaderGroup `xml:"headerGroup"`
+	Columns      []Column      `xml:"column"`
 }
 
-// Column represents a table column definition.
+// HeaderGroup is an extra header row whose cells span one or more columns.
+type HeaderGroup struct {
+	Cells []HeaderGroupCell `xml:"cell"`
+}
+
+// HeaderGroupCell is a header cell spanning Colspan columns (default: 1). A
+// cell without a Header leaves those columns out of its header row.
+type HeaderGroupCell struct {
+	Header  string `xml:"header,attr"`
+	Colspan int    `xml:"colspan,attr"`
+	Align   string `xml:"align,attr"`
+}
+
+// Column represents a table column definition. When the column body holds a
+// template it is rendered per row instead of the raw field value.
 type Column struct {
-	Header string  `xml:"header,attr"`
-	Field  string  `xml:"field,attr"`
+	Header string `xml:"header,attr"`
+	Field  string `xml:"field,attr"`
+
+	// Width is an absolute width, a percentage of the flow width ("25%"),
+	// "auto" to fit the header and content, or "*" to share the remaining
+	// space ("2*" takes twice the share). Empty behaves like "*".
+	Width    string `xml:"width,attr"`
+	Align    string `xml:"align,attr"`
+	Format   string `xml:"format,attr"`
+	Wrap     bool   `xml:"wrap,attr"`
+	Template string `xml:",chardata"`
+
+	// Type draws the field as a "sparkline" or "progressBar" instead of
+	// text. Max and Color configure the graphic.
+	Type  string  `xml:"type,attr"`
+	Max   float64 `xml:"max,attr"`
+	Color string  `xml:"color,attr"`
+
+	// CellStyle colors the cell when CellStyleCondition holds for the row.
+	CellStyle          string `xml:"cellStyle,attr"`
+	CellStyleCondition string `xml:"cellStyleCondition,attr"`
+}
+
+// TableRowStyle applies a named style's colors to every data row for which
+// the condition holds.
+type TableRowStyle struct {
+	Condition string `xml:"condition,attr"`
+	Style     string `xml:"style,attr"`
+}
+
+// TableGroup groups table rows by a field value. Each group opens with a
+// label row and, when the table has a footer, closes with a subtotal row.
+type TableGroup struct {
+	Field     string `xml:"field,attr"`
+	Label     string `xml:"label,attr"`
+	Style     string `xml:"style,attr"`
+	Subtotals *bool  `xml:"subtotals,attr"`
+}
+
+// TableFooter defines the summary rows rendered as group subtotals and as the
+// grand total at the end of a table.
+type TableFooter struct {
+	Style         string            `xml:"style,attr"`
+	SubtotalStyle string            `xml:"subtotalStyle,attr"`
+	Cells         []TableFooterCell `xml:"cell"`
+}
+
+// TableFooterCell is a summary cell matched to table columns by position,
+// spanning Colspan columns (default: 1). It shows either an aggregate of the
+// first spanned column's values or a label.
+type TableFooterCell struct {
+	Label         string `xml:"label,attr"`
+	SubtotalLabel string `xml:"subtotalLabel,attr"`
+	Field         string `xml:"field,attr"`
+	Aggregate     string `xml:"aggregate,attr"`
+	Format        string `xml:"format,attr"`
+	Align         string `xml:"align,attr"`
+	Colspan       int    `xml:"colspan,attr"`
+}
+
+// Pivot renders a crosstab of a flat data source: one row per distinct
+// RowField value and one column per distinct ColumnField value, with each
+// cell aggregating ValueField over the matching items.
+type Pivot struct {
+	BaseElement
+	DataQuery
+	DataSource  string `xml:"dataSource,attr"`
+	RowField    string `xml:"rowField,attr"`
+	ColumnField string `xml:"columnField,attr"`
+	ValueField  string `xml:"valueField,attr"`
+	Aggregate   string `xml:"aggregate,attr"`
+	Format      string `xml:"format,attr"`
+
+	// RowHeader labels the row value column (default: RowField).
+	RowHeader   string `xml:"rowHeader,attr"`
+	RowWidth    string `xml:"rowWidth,attr"`
+	ColumnWidth string `xml:"columnWidth,attr"`
+
+	// RowTotals and ColumnTotals add a total column and a total row.
+	RowTotals    *bool  `xml:"rowTotals,attr"`
+	ColumnTotals *bool  `xml:"columnTotals,attr"`
+	TotalLabel   string `xml:"totalLabel,attr"`
+
+	HeaderStyle       string    `xml:"headerStyle,attr"`
+	CellStyle         string    `xml:"cellStyle,attr"`
+	TotalStyle        string    `xml:"totalStyle,attr"`
+	Border            bool      `xml:"border,attr"`
+	RepeatHeader      *bool     `xml:"repeatHeader,attr"`
+	AlternateRowColor *RGBColor `xml:"alternateRowColor"`
+}
+
+// GetType returns the element type.
+func (p Pivot) GetType() string { return "pivot" }
+
+// GetCondition returns the condition for rendering.
+func (p Pivot) GetCondition() string { return p.Condition }
+
+// Chart renders a bar, line, pie or stacked bar chart as vector graphics.
+// Each data item provides a category label from LabelField and one value per
+// series. Without series children, ValueField forms a single series.
+type Chart struct {
+	BaseElement
+	DataQuery
+	Type       string        `xml:"type,attr"`
+	DataSource string        `xml:"dataSource,attr"`
+	LabelField string        `xml:"labelField,attr"`
+	ValueField string        `xml:"valueField,attr"`
+	Series     []ChartSeries `xml:"series"`
+
+	// Width defaults to the current flow width and Height to 60.
 	Width  float64 `xml:"width,attr"`
-	Align  string  `xml:"align,attr"`
-	Format string  `xml:"format,attr"`
+	Height float64 `xml:"height,attr"`
+
+	Title      string `xml:"title,attr"`
+	XAxisTitle string `xml:"xAxisTitle,attr"`
+	YAxisTitle string `xml:"yAxisTitle,attr"`
+	Format     string `xml:"format,attr"`
+	Legend     *bool  `xml:"legend,attr"`
+
+	// Style sets the font of titles and labels. Styles lists named styles
+	// whose fill colors are used in turn for series and pie slices.
+	Style  string `xml:"style,attr"`
+	Styles string `xml:"styles,attr"`
+}
+
+// ChartSeries is one value series of a chart.
+type ChartSeries struct {
+	Field string `xml:"field,attr"`
+	Label string `xml:"label,attr"`
+	Style string `xml:"style,attr"`
+}
+
+// GetType returns the element type.
+func (c Chart) GetType() string { return "chart" }
+
+// GetCondition returns the condition for rendering.
+func (c Chart) GetCondition() string { return c.Condition }
+
+// Sparkline draws a small trend line through a series of numbers. Items of
+// DataSource are used as values directly, or through ValueField.
+type Sparkline struct {
+	BaseElement
+	DataSource string  `xml:"dataSource,attr"`
+	ValueField string  `xml:"valueField,attr"`
+	Width      float64 `xml:"width,attr"`
+	Height     float64 `xml:"height,attr"`
+	Color      string  `xml:"color,attr"`
+	LineWidth  float64 `xml:"lineWidth,attr"`
+}
+
+// GetType returns the element type.
+func (s Sparkline) GetType() string { return "sparkline" }
+
+// GetCondition returns the condition for rendering.
+func (s Sparkline) GetCondition() string { return s.Condition }
+
+// ProgressBar draws a bar filled in proportion to Value out of Max
+// (default: 1).
+type ProgressBar struct {
+	BaseElement
+	Value           string  `xml:"value,attr"`
+	Max             float64 `xml:"max,attr"`
+	Width           float64 `xml:"width,attr"`
+	Height          float64 `xml:"height,attr"`
+	Color           string  `xml:"color,attr"`
+	BackgroundColor string  `xml:"backgroundColor,attr"`
+}
+
+// GetType returns the element type.
+func (p ProgressBar) GetType() string { return "progressBar" }
+
+// GetCondition returns the condition for rendering.
+func (p ProgressBar) GetCondition() string { return p.Condition }
+
+// QRCode draws Value as a QR code symbol of Size millimetres per side,
+// including a light quiet zone QuietZone modules wide (default: 4). It is
+// positioned like an Image.
+type QRCode struct {
+	BaseElement
+	Value           string  `xml:"value,attr"`
+	X               float64 `xml:"x,attr"`
+	Y               float64 `xml:"y,attr"`
+	Size            float64 `xml:"size,attr"`
+	Align           string  `xml:"align,attr"`
+	ErrorCorrection string  `xml:"errorCorrection,attr"`
+	Color           string  `xml:"color,attr"`
+	QuietZone       *int    `xml:"quietZone,attr"`
+}
+
+// GetType returns the element type.
+func (q QRCode) GetType() string { return "qrcode" }
+
+// GetCondition returns the condition for rendering.
+func (q QRCode) GetCondition() string { return q.Condition }
+
+// Barcode draws Value as a linear barcode. Type is code128, code39, ean13
+// or upca. It is positioned like an Image. Width includes a light quiet zone
+// QuietZone modules wide on either side (default: 10), and Height includes
+// the human-readable text line when ShowText is on (default: true).
+type Barcode struct {
+	BaseElement
+	Type      string  `xml:"type,attr"`
+	Value     string  `xml:"value,attr"`
+	X         float64 `xml:"x,attr"`
+	Y         float64 `xml:"y,attr"`
+	Width     float64 `xml:"width,attr"`
+	Height    float64 `xml:"height,attr"`
+	Align     string  `xml:"align,attr"`
+	ShowText  *bool   `xml:"showText,attr"`
+	Style     string  `xml:"style,attr"`
+	Color     string  `xml:"color,attr"`
+	QuietZone *int    `xml:"quietZone,attr"`
+}
+
+// GetType returns the element type.
+func (b Barcode) GetType() string { return "barcode" }
+
+// GetCondition returns the condition for rendering.
+func (b Barcode) GetCondition() string { return b.Condition }
+
+// Toc draws the table of contents from sections and texts marked with
+// tocEntry, one line per entry with dot leaders and its page number. Entries
+// are indented by Indent (default: 5) per level below 1.
+type Toc struct {
+	BaseElement
+	Style    string  `xml:"style,attr"`
+	Leader   string  `xml:"leader,attr"`
+	Indent   float64 `xml:"indent,attr"`
+	MaxLevel int     `xml:"maxLevel,attr"`
 }
 
+// GetType returns the element type.
+func (t Toc) GetType() string { return "toc" }
+
+// GetCondition returns the condition for rendering.
+func (t Toc) GetCondition() string { return t.Condition }
+
 // List represents a list element.
 type List struct {
 	BaseElement
+	DataQuery
 	Items  string  `xml:"items,attr"`
 	Style  string  `xml:"style,attr"`
 	Bullet string  `xml:"bullet,attr"`
@@ -176,11 +461,21 @@ func (r Row) GetType() string { return "row" }
 // GetCondition returns the condition for rendering.
 func (r Row) GetCondition() string { return r.Condition }
 
-// RowGrid represents an equal-width multi-column flow container.
+// RowGrid represents a multi-column flow container. Columns share the flow
+// width equally unless Widths or the columns themselves set weights or fixed
+// widths.
 type RowGrid struct {
 	BaseElement
-	Columns int             `xml:"columns,attr"`
-	Cols    []RowGridColumn `xml:"-"`
+	Columns int `xml:"columns,attr"`
+	// Widths lists column weights, such as "2,1,1".
+	Widths string `xml:"widths,attr"`
+	// Gap is the space between columns. Divider draws a vertical line in
+	// each gap, in DividerColor and DividerWidth when set.
+	Gap          float64         `xml:"gap,attr"`
+	Divider      bool            `xml:"divider,attr"`
+	DividerColor string          `xml:"dividerColor,attr"`
+	DividerWidth float64         `xml:"dividerWidth,attr"`
+	Cols         []RowGridColumn `xml:"-"`
 }
 
 // GetType returns the element type.
@@ -189,8 +484,30 @@ func (r RowGrid) GetType() string { return "rowgrid" }
 // GetCondition returns the condition for rendering.
 func (r RowGrid) GetCondition() string { return r.Condition }
 
-// RowGridColumn represents a single equal-width column within a rowgrid.
+// Box represents a flow container that draws a background and border
+// behind its child elements, sized to their height.
+type Box struct {
+	BaseElement
+	Padding     float64
+	Radius      float64
+	BorderWidth float64
+	FillColor   *RGBColor
+	BorderColor *RGBColor
+	Elements    []SectionElement `xml:"-"`
+}
+
+// GetType returns the element type.
+func (b Box) GetType() string { return "box" }
+
+// GetCondition returns the condition for rendering.
+func (b Box) GetCondition() string { return b.Condition }
+
+// RowGridColumn represents a single column within a rowgrid. Width fixes
+// the column width; otherwise Weight overrides its weight in the rowgrid
+// widths.
 type RowGridColumn struct {
+	Width    float64          `xml:"width,attr"`
+	Weight   float64          `xml:"weight,attr"`
 	Elements []SectionElement `xml:"-"`
 }
 
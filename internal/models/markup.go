@@ -0,0 +1,193 @@
+package models
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TextRun is a span of text content with the inline formatting applied to
+// it by markup such as <b> or <span style="...">.
+type TextRun struct {
+	Content   string
+	Bold      bool
+	Italic    bool
+	Underline bool
+	// Style names a style applied under the element style.
+	Style string
+	// Break marks a forced line break (<br/>) instead of content.
+	Break bool
+	// Footnote is the note text of a <footnote>, which the run marks with
+	// its number instead of content. FootnoteStyle styles the note.
+	Footnote      string
+	FootnoteStyle string
+	// FootnoteRef is the id of a footnote declared under <footnotes>, which
+	// the run marks instead of a note of its own.
+	FootnoteRef string
+}
+
+// Footnotes contains footnotes declared once and marked in text by id.
+type Footnotes struct {
+	Footnotes []Footnote `xml:"footnote"`
+}
+
+// Footnote is a declared footnote.
+type Footnote struct {
+	ID      string `xml:"id,attr"`
+	Style   string `xml:"style,attr"`
+	Content string `xml:",chardata"`
+}
+
+// UnmarshalXML implements custom XML unmarshaling for text elements. Text
+// containing inline markup is split into Runs, and Content holds the same
+// text without markup.
+func (t *Text) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plainText Text
+	var raw struct {
+		plainText
+		InnerXML string `xml:",innerxml"`
+	}
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+	*t = Text(raw.plainText)
+
+	if !strings.Contains(raw.InnerXML, "<") {
+		return nil
+	}
+
+	runs, err := parseTextMarkup(raw.InnerXML)
+	if err != nil {
+		return err
+	}
+	if runs == nil {
+		return nil
+	}
+
+	var content strings.Builder
+	for _, run := range runs {
+		if run.Break {
+			content.WriteString("\n")
+		} else {
+			content.WriteString(run.Content)
+		}
+	}
+	t.Content = content.String()
+	t.Runs = runs
+
+	return nil
+}
+
+// parseTextMarkup splits inner text XML into runs. It returns nil when the
+// text has no inline elements, for example when it only uses CDATA.
+func parseTextMarkup(inner string) ([]TextRun, error) {
+	d := xml.NewDecoder(strings.NewReader("<markup>" + inner + "</markup>"))
+
+	var (
+		runs      []TextRun
+		stack     []TextRun
+		hasMarkup bool
+	)
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid text markup: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 {
+				// The wrapper element adds no formatting.
+				stack = append(stack, TextRun{})
+				continue
+			}
+			hasMarkup = true
+
+			format := stack[len(stack)-1]
+			switch tok.Name.Local {
+			case "b", "strong":
+				format.Bold = true
+			case "i", "em":
+				format.Italic = true
+			case "u":
+				format.Underline = true
+			case "span":
+				for _, attr := range tok.Attr {
+					if attr.Name.Local == "style" {
+						format.Style = attr.Value
+					}
+				}
+			case "br":
+				runs = append(runs, TextRun{Break: true})
+			case "footnote":
+				note, err := decodeFootnote(d, tok)
+				if err != nil {
+					return nil, err
+				}
+				format.Footnote = note
+				for _, attr := range tok.Attr {
+					switch attr.Name.Local {
+					case "style":
+						format.FootnoteStyle = attr.Value
+					case "ref":
+						format.FootnoteRef = strings.TrimSpace(attr.Value)
+					}
+				}
+				if format.FootnoteRef != "" && note != "" {
+					return nil, fmt.Errorf("footnote %q cannot have note text of its own", format.FootnoteRef)
+				}
+				runs = append(runs, format)
+				// decodeFootnote consumed the end element.
+				continue
+			default:
+				return nil, fmt.Errorf("unsupported inline element <%s> in text", tok.Name.Local)
+			}
+			stack = append(stack, format)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			format := stack[len(stack)-1]
+			format.Content = string(tok)
+			if last := len(runs) - 1; last >= 0 && !runs[last].Break && runs[last].Footnote == "" && runs[last].FootnoteRef == "" && sameFormat(runs[last], format) {
+				runs[last].Content += format.Content
+				continue
+			}
+			runs = append(runs, format)
+		}
+	}
+
+	if !hasMarkup {
+		return nil, nil
+	}
+	return runs, nil
+}
+
+func sameFormat(a, b TextRun) bool {
+	return a.Bold == b.Bold && a.Italic == b.Italic && a.Underline == b.Underline && a.Style == b.Style
+}
+
+// decodeFootnote reads the plain text of a <footnote> up to its end element.
+func decodeFootnote(d *xml.Decoder, start xml.StartElement) (string, error) {
+	var note strings.Builder
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return "", fmt.Errorf("invalid text markup: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			return "", fmt.Errorf("unsupported element <%s> in footnote", tok.Name.Local)
+		case xml.EndElement:
+			if tok.Name == start.Name {
+				return strings.TrimSpace(note.String()), nil
+			}
+		case xml.CharData:
+			note.Write(tok)
+		}
+	}
+}
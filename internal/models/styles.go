@@ -40,6 +40,11 @@ type Style struct {
 	FillColor  *RGBColor `xml:"fillColor"`
 	Align      string    `xml:"align"`
 	LineHeight float64   `xml:"lineHeight"`
+	// MinLinesBefore and MinLinesAfter are the fewest lines of a wrapped
+	// paragraph left at the bottom of a page (orphans) and carried to the
+	// next page (widows) when the paragraph breaks across pages.
+	MinLinesBefore int `xml:"minLinesBefore"`
+	MinLinesAfter  int `xml:"minLinesAfter"`
 }
 
 // Merge overlays non-zero values from the child style on top of the receiver.
@@ -69,6 +74,12 @@ func (s Style) Merge(child Style) Style {
 	if child.LineHeight > 0 {
 		merged.LineHeight = child.LineHeight
 	}
+	if child.MinLinesBefore > 0 {
+		merged.MinLinesBefore = child.MinLinesBefore
+	}
+	if child.MinLinesAfter > 0 {
+		merged.MinLinesAfter = child.MinLinesAfter
+	}
 
 	return merged
 }
@@ -3,14 +3,15 @@ package models
 
 // Report represents the root element of a report template.
 type Report struct {
-	Version  string    `xml:"version,attr"`
-	Metadata *Metadata `xml:"metadata"`
-	Document Document  `xml:"document"`
-	Fonts    *Fonts    `xml:"fonts"`
-	Styles   *Styles   `xml:"styles"`
-	Header   *Header   `xml:"header"`
-	Footer   *Footer   `xml:"footer"`
-	Sections Sections  `xml:"sections"`
+	Version   string     `xml:"version,attr"`
+	Metadata  *Metadata  `xml:"metadata"`
+	Document  Document   `xml:"document"`
+	Fonts     *Fonts     `xml:"fonts"`
+	Styles    *Styles    `xml:"styles"`
+	Footnotes *Footnotes `xml:"footnotes"`
+	Header    *Header    `xml:"header"`
+	Footer    *Footer    `xml:"footer"`
+	Sections  Sections   `xml:"sections"`
 }
 
 // Metadata contains template metadata.
@@ -1,6 +1,12 @@
 package parser
 
-import "testing"
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dannyswat/reportgo/internal/models"
+)
 
 func TestParseTemplateResolvesStyleInheritance(t *testing.T) {
 	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
@@ -125,3 +131,726 @@ func TestParseTemplateParsesRowGridColumns(t *testing.T) {
 		t.Fatalf("expected nested row child in second col, got %s", rowGrid.Cols[1].Elements[0].Type)
 	}
 }
+
+func TestParseTemplateParsesTableGroupingAndFooter(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <table dataSource="{{.Rows}}">
+                <columns>
+                    <column header="Name" field="name" width="60"/>
+                    <column header="Amount" field="amount" width="30" format="currency"/>
+                </columns>
+                <groupBy field="department" label="{{.group}}" subtotals="false"/>
+                <footer style="total">
+                    <cell label="Total"/>
+                    <cell aggregate="sum"/>
+                </footer>
+            </table>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	table := report.Sections.Sections[0].Elements[0].Table
+	if table == nil {
+		t.Fatalf("expected table element")
+	}
+	if table.GroupBy == nil || table.GroupBy.Field != "department" {
+		t.Fatalf("expected groupBy field to be parsed, got %#v", table.GroupBy)
+	}
+	if table.GroupBy.Subtotals == nil || *table.GroupBy.Subtotals {
+		t.Fatalf("expected subtotals opt-out to be parsed")
+	}
+	if table.Footer == nil || len(table.Footer.Cells) != 2 {
+		t.Fatalf("expected 2 footer cells, got %#v", table.Footer)
+	}
+	if table.Footer.Cells[1].Aggregate != "sum" {
+		t.Fatalf("expected sum aggregate on second footer cell, got %q", table.Footer.Cells[1].Aggregate)
+	}
+}
+
+func TestParseTemplateParsesColumnCellTemplate(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <table dataSource="{{.Items}}">
+                <columns>
+                    <column header="Name" field="Name" width="60"/>
+                    <column header="Line Total" width="30" align="R">{{mul .row.Qty .row.Price | formatCurrency}}</column>
+                </columns>
+            </table>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	columns := report.Sections.Sections[0].Elements[0].Table.Columns.Columns
+	if len(columns) != 2 {
+		t.Fatalf("expected 2 columns, got %d", len(columns))
+	}
+	if columns[0].Template != "" {
+		t.Fatalf("expected no template on a field column, got %q", columns[0].Template)
+	}
+	if got := columns[1].Template; got != "{{mul .row.Qty .row.Price | formatCurrency}}" {
+		t.Fatalf("expected the column body as its cell template, got %q", got)
+	}
+	if columns[1].Align != "R" {
+		t.Fatalf("expected template column attributes to be parsed, got align %q", columns[1].Align)
+	}
+}
+
+func TestParseTemplateParsesTableHeaderGroupsAndColspan(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <table dataSource="{{.Rows}}">
+                <columns>
+                    <headerGroup>
+                        <cell header=""/>
+                        <cell header="Q1" colspan="3"/>
+                    </headerGroup>
+                    <column header="Region" field="region" width="40"/>
+                    <column header="Jan" field="jan" width="20"/>
+                    <column header="Feb" field="feb" width="20"/>
+                    <column header="Mar" field="mar" width="20"/>
+                </columns>
+                <footer>
+                    <cell label="Total" colspan="2"/>
+                    <cell aggregate="sum" field="feb"/>
+                </footer>
+            </table>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	table := report.Sections.Sections[0].Elements[0].Table
+	if table == nil {
+		t.Fatalf("expected table element")
+	}
+	if len(table.Columns.HeaderGroups) != 1 || len(table.Columns.HeaderGroups[0].Cells) != 2 {
+		t.Fatalf("expected one header group with 2 cells, got %#v", table.Columns.HeaderGroups)
+	}
+	if cell := table.Columns.HeaderGroups[0].Cells[1]; cell.Header != "Q1" || cell.Colspan != 3 {
+		t.Fatalf("expected Q1 to span 3 columns, got %#v", cell)
+	}
+	if len(table.Columns.Columns) != 4 {
+		t.Fatalf("expected 4 columns, got %d", len(table.Columns.Columns))
+	}
+	if table.Footer.Cells[0].Colspan != 2 {
+		t.Fatalf("expected footer label to span 2 columns, got %d", table.Footer.Cells[0].Colspan)
+	}
+}
+
+func TestParseTemplateParsesDataQueryAttributes(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="customers" loop="{{.Customers}}" loopVariable="customer"
+                 sortBy="name" sortOrder="asc" filter="{{.customer.active}}" limit="5">
+            <table dataSource="{{.customer.Invoices}}" sortBy="due" sortOrder="desc" limit="10" filter="{{not .row.paid}}">
+                <columns>
+                    <column header="Number" field="number" width="40"/>
+                </columns>
+            </table>
+            <list items="{{.customer.Tags}}" sortBy="." limit="3"/>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	section := report.Sections.Sections[0]
+	if section.SortBy != "name" || section.SortOrder != "asc" || section.Filter != "{{.customer.active}}" || section.Limit != 5 {
+		t.Fatalf("expected section loop query to be parsed, got %#v", section.DataQuery)
+	}
+
+	table := section.Elements[0].Table
+	if table == nil || table.SortBy != "due" || table.SortOrder != "desc" || table.Limit != 10 || table.Filter != "{{not .row.paid}}" {
+		t.Fatalf("expected table query to be parsed, got %#v", table)
+	}
+
+	list := section.Elements[1].List
+	if list == nil || list.SortBy != "." || list.Limit != 3 {
+		t.Fatalf("expected list query to be parsed, got %#v", list)
+	}
+}
+
+func TestParseTemplateParsesPivot(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <pivot dataSource="{{.Sales}}" rowField="region" columnField="month" valueField="amount"
+                   aggregate="sum" format="currency" rowHeader="Region" rowTotals="false" totalStyle="total"/>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	elem := report.Sections.Sections[0].Elements[0]
+	if elem.Type != "pivot" || elem.Pivot == nil {
+		t.Fatalf("expected pivot element, got %s", elem.Type)
+	}
+	pivot := elem.Pivot
+	if pivot.RowField != "region" || pivot.ColumnField != "month" || pivot.ValueField != "amount" || pivot.Format != "currency" {
+		t.Fatalf("expected pivot fields to be parsed, got %#v", pivot)
+	}
+	if pivot.RowTotals == nil || *pivot.RowTotals {
+		t.Fatalf("expected rowTotals opt-out to be parsed")
+	}
+}
+
+func TestParseTemplateParsesChartsInSectionsAndRows(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <chart type="stackedBar" dataSource="{{.Sales}}" labelField="region" height="70" legend="false" styles="a,b">
+                <series field="q1" label="Q1" style="blue"/>
+                <series field="q2" label="Q2"/>
+            </chart>
+            <row>
+                <text style="body" width="40">Trend</text>
+                <chart type="line" dataSource="{{.Sales}}" labelField="month" valueField="total" width="60" height="30"/>
+            </row>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	elements := report.Sections.Sections[0].Elements
+	chart := elements[0].Chart
+	if elements[0].Type != "chart" || chart == nil {
+		t.Fatalf("expected chart element, got %s", elements[0].Type)
+	}
+	if chart.Type != "stackedBar" || chart.Height != 70 || len(chart.Series) != 2 || chart.Series[0].Style != "blue" {
+		t.Fatalf("expected chart attributes and series to be parsed, got %#v", chart)
+	}
+	if chart.Legend == nil || *chart.Legend {
+		t.Fatalf("expected legend opt-out to be parsed")
+	}
+
+	row := elements[1].Row
+	if row == nil || len(row.Elements) != 2 || row.Elements[1].Type != "chart" {
+		t.Fatalf("expected chart inside row, got %#v", row)
+	}
+	if row.Elements[1].Chart.Width != 60 {
+		t.Fatalf("expected row chart width 60, got %.2f", row.Elements[1].Chart.Width)
+	}
+}
+
+func TestParseTemplateParsesSparklineAndProgressBar(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <sparkline dataSource="{{.History}}" width="40" height="8" color="#336699"/>
+            <progressBar value="{{.Done}}" max="100" width="50"/>
+            <table dataSource="{{.Metrics}}">
+                <columns>
+                    <column header="Trend" field="History" width="30" type="sparkline"/>
+                    <column header="Target" field="Progress" width="30" type="progressBar" max="100" color="green"/>
+                </columns>
+            </table>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	elements := report.Sections.Sections[0].Elements
+	if elements[0].Sparkline == nil || elements[0].Sparkline.DataSource != "{{.History}}" || elements[0].Sparkline.Height != 8 {
+		t.Fatalf("expected sparkline to be parsed, got %#v", elements[0])
+	}
+	if elements[1].Progress == nil || elements[1].Progress.Value != "{{.Done}}" || elements[1].Progress.Max != 100 {
+		t.Fatalf("expected progress bar to be parsed, got %#v", elements[1])
+	}
+
+	columns := elements[2].Table.Columns.Columns
+	if columns[0].Type != "sparkline" || columns[1].Type != "progressBar" || columns[1].Max != 100 || columns[1].Color != "green" {
+		t.Fatalf("expected graphic column types to be parsed, got %#v", columns)
+	}
+}
+
+func TestParseTemplateParsesQRCode(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <footer enabled="true" height="30">
+        <qrcode value="{{.URL}}" x="180" y="2" size="20"/>
+    </footer>
+    <sections>
+        <section name="main">
+            <qrcode value="{{.URL}}" size="25" errorCorrection="H" align="R" quietZone="2"/>
+            <row>
+                <qrcode value="{{.Ref}}" size="15"/>
+            </row>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	if qrCodes := report.Footer.QRCodes; len(qrCodes) != 1 || qrCodes[0].Size != 20 || qrCodes[0].X != 180 {
+		t.Fatalf("expected footer QR code to be parsed, got %#v", qrCodes)
+	}
+
+	elements := report.Sections.Sections[0].Elements
+	qr := elements[0].QRCode
+	if qr == nil || qr.Value != "{{.URL}}" || qr.ErrorCorrection != "H" || qr.Align != "R" {
+		t.Fatalf("expected QR code to be parsed, got %#v", elements[0])
+	}
+	if qr.QuietZone == nil || *qr.QuietZone != 2 {
+		t.Fatalf("expected QR code quiet zone to be parsed, got %v", qr.QuietZone)
+	}
+	if row := elements[1].Row; len(row.Elements) != 1 || row.Elements[0].QRCode == nil || row.Elements[0].QRCode.Size != 15 {
+		t.Fatalf("expected row QR code to be parsed, got %#v", row)
+	}
+}
+
+func TestParseTemplateParsesBarcode(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <barcode type="code128" value="{{.SKU}}" width="60" height="18" showText="false" quietZone="12"/>
+            <row>
+                <barcode type="ean13" value="{{.EAN}}" width="40" style="small"/>
+            </row>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	elements := report.Sections.Sections[0].Elements
+	bc := elements[0].Barcode
+	if bc == nil || bc.Type != "code128" || bc.Value != "{{.SKU}}" || bc.Width != 60 || bc.Height != 18 {
+		t.Fatalf("expected barcode to be parsed, got %#v", elements[0])
+	}
+	if bc.ShowText == nil || *bc.ShowText {
+		t.Fatalf("expected showText=false to be parsed, got %v", bc.ShowText)
+	}
+	if bc.QuietZone == nil || *bc.QuietZone != 12 {
+		t.Fatalf("expected barcode quiet zone to be parsed, got %v", bc.QuietZone)
+	}
+	if row := elements[1].Row; len(row.Elements) != 1 || row.Elements[0].Barcode == nil || row.Elements[0].Barcode.Style != "small" {
+		t.Fatalf("expected row barcode to be parsed, got %#v", row)
+	}
+}
+
+func TestParseTemplateParsesInlineTextMarkup(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <text style="body" width="120" align="C">Total <b>{{.Total}}</b> is <i>due <u>today</u></i>.<br/><span style="note">Thank you &amp; goodbye</span></text>
+            <text style="body">Plain {{.Name}}</text>
+            <text style="body"><![CDATA[a < b]]></text>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	elements := report.Sections.Sections[0].Elements
+	rich := elements[0].Text
+	if rich.Width != 120 || rich.Align != "C" || rich.Style != "body" {
+		t.Fatalf("expected text attributes to be parsed, got %#v", rich)
+	}
+
+	want := []models.TextRun{
+		{Content: "Total "},
+		{Content: "{{.Total}}", Bold: true},
+		{Content: " is "},
+		{Content: "due ", Italic: true},
+		{Content: "today", Italic: true, Underline: true},
+		{Content: "."},
+		{Break: true},
+		{Content: "Thank you & goodbye", Style: "note"},
+	}
+	if !reflect.DeepEqual(rich.Runs, want) {
+		t.Fatalf("unexpected runs:\n got %#v\nwant %#v", rich.Runs, want)
+	}
+	if rich.Content != "Total {{.Total}} is due today.\nThank you & goodbye" {
+		t.Fatalf("expected plain content without markup, got %q", rich.Content)
+	}
+
+	if plain := elements[1].Text; plain.Runs != nil || plain.Content != "Plain {{.Name}}" {
+		t.Fatalf("expected plain text to stay unchanged, got %#v", plain)
+	}
+	if cdata := elements[2].Text; cdata.Runs != nil || cdata.Content != "a < b" {
+		t.Fatalf("expected CDATA text to stay plain, got %#v", cdata)
+	}
+}
+
+func TestParseTemplateRejectsUnknownInlineMarkup(t *testing.T) {
+	_, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <text>Hello <blink>world</blink></text>
+        </section>
+    </sections>
+</report>`)
+	if err == nil || !strings.Contains(err.Error(), "blink") {
+		t.Fatalf("expected unsupported markup error, got %v", err)
+	}
+}
+
+func TestParseTemplateParsesLinksAndAnchors(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <text href="#appendix">See <b>Appendix</b></text>
+            <image path="logo.png" width="20" href="https://example.com"/>
+            <row anchor="totals">
+                <text href="#main">Top</text>
+            </row>
+        </section>
+        <section name="appendix" anchor="appendix">
+            <table anchor="items"/>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	main, appendix := report.Sections.Sections[0], report.Sections.Sections[1]
+	if text := main.Elements[0].Text; text.Href != "#appendix" || len(text.Runs) == 0 {
+		t.Fatalf("expected text href to be parsed with markup, got %#v", text)
+	}
+	if img := main.Elements[1].Image; img.Href != "https://example.com" {
+		t.Fatalf("expected image href to be parsed, got %#v", img)
+	}
+	if row := main.Elements[2].Row; row.Anchor != "totals" || row.Elements[0].Text.Href != "#main" {
+		t.Fatalf("expected row anchor and nested href to be parsed, got %#v", row)
+	}
+	if appendix.Anchor != "appendix" || appendix.Elements[0].Table.Anchor != "items" {
+		t.Fatalf("expected section and table anchors to be parsed, got %#v", appendix)
+	}
+}
+
+func TestParseTemplateParsesTableOfContents(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="contents">
+            <toc style="body" leader="-" indent="4" maxLevel="2"/>
+        </section>
+        <section name="Summary" tocEntry="true" tocLevel="1">
+            <text tocEntry="Revenue" tocLevel="2">Revenue figures</text>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	toc := report.Sections.Sections[0].Elements[0].Toc
+	if toc == nil || toc.Style != "body" || toc.Leader != "-" || toc.Indent != 4 || toc.MaxLevel != 2 {
+		t.Fatalf("expected toc to be parsed, got %#v", report.Sections.Sections[0].Elements[0])
+	}
+	summary := report.Sections.Sections[1]
+	if summary.TocEntry != "true" || summary.TocLevel != 1 {
+		t.Fatalf("expected section toc attributes to be parsed, got %#v", summary)
+	}
+	if text := summary.Elements[0].Text; text.TocEntry != "Revenue" || text.TocLevel != 2 {
+		t.Fatalf("expected text toc attributes to be parsed, got %#v", text)
+	}
+}
+
+func TestParseTemplateParsesSectionTitleAndBookmark(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="summary" title="Executive Summary"/>
+        <section name="cover" bookmark="false"/>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	summary, cover := report.Sections.Sections[0], report.Sections.Sections[1]
+	if summary.Title != "Executive Summary" || summary.Bookmark != nil {
+		t.Fatalf("expected section title to be parsed, got %#v", summary)
+	}
+	if cover.Bookmark == nil || *cover.Bookmark {
+		t.Fatalf("expected bookmark=false to be parsed, got %v", cover.Bookmark)
+	}
+}
+
+func TestParseTemplateParsesInlineFootnotes(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <text>Revenue grew<footnote style="note">Unaudited {{.Year}}.</footnote> this year.</text>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	text := report.Sections.Sections[0].Elements[0].Text
+	want := []models.TextRun{
+		{Content: "Revenue grew"},
+		{Footnote: "Unaudited {{.Year}}.", FootnoteStyle: "note"},
+		{Content: " this year."},
+	}
+	if !reflect.DeepEqual(text.Runs, want) {
+		t.Fatalf("expected footnote run, got %#v", text.Runs)
+	}
+	if text.Content != "Revenue grew this year." {
+		t.Fatalf("expected note text to be left out of content, got %q", text.Content)
+	}
+
+	_, err = ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <text>Revenue<footnote>See <b>this</b></footnote></text>
+        </section>
+    </sections>
+</report>`)
+	if err == nil || !strings.Contains(err.Error(), "footnote") {
+		t.Fatalf("expected nested footnote markup error, got %v", err)
+	}
+}
+
+func TestParseTemplateParsesDeclaredFootnotes(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <footnotes>
+        <footnote id="unaudited" style="note">Unaudited {{.Year}}.</footnote>
+    </footnotes>
+    <sections>
+        <section name="main">
+            <text>Revenue<footnote ref="unaudited"/> and costs<footnote ref="unaudited"/> grew.</text>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	want := []models.Footnote{{ID: "unaudited", Style: "note", Content: "Unaudited {{.Year}}."}}
+	if report.Footnotes == nil || !reflect.DeepEqual(report.Footnotes.Footnotes, want) {
+		t.Fatalf("expected declared footnote, got %#v", report.Footnotes)
+	}
+	text := report.Sections.Sections[0].Elements[0].Text
+	wantRuns := []models.TextRun{
+		{Content: "Revenue"},
+		{FootnoteRef: "unaudited"},
+		{Content: " and costs"},
+		{FootnoteRef: "unaudited"},
+		{Content: " grew."},
+	}
+	if !reflect.DeepEqual(text.Runs, wantRuns) {
+		t.Fatalf("expected footnote reference runs, got %#v", text.Runs)
+	}
+
+	_, err = ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <text>Revenue<footnote ref="unaudited">Unaudited.</footnote></text>
+        </section>
+    </sections>
+</report>`)
+	if err == nil || !strings.Contains(err.Error(), "unaudited") {
+		t.Fatalf("expected error for a footnote reference with note text, got %v", err)
+	}
+}
+
+func TestParseTemplateParsesPaginationControls(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main" keepTogether="true">
+            <text keepWithNext="true">Items</text>
+            <table dataSource="{{.Items}}" keepTogether="true"/>
+            <row keepTogether="true"/>
+            <rowgrid columns="2" keepTogether="true"/>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	section := report.Sections.Sections[0]
+	if !section.KeepTogether {
+		t.Fatalf("expected section keepTogether to be parsed")
+	}
+	elements := section.Elements
+	if !elements[0].Text.KeepWithNext {
+		t.Fatalf("expected text keepWithNext to be parsed")
+	}
+	if !elements[1].Table.KeepTogether || !elements[2].Row.KeepTogether || !elements[3].RowGrid.KeepTogether {
+		t.Fatalf("expected element keepTogether to be parsed, got %#v", elements)
+	}
+}
+
+func TestParseTemplateParsesMinLines(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <styles>
+        <style name="body">
+            <minLinesBefore>2</minLinesBefore>
+            <minLinesAfter>3</minLinesAfter>
+        </style>
+    </styles>
+    <sections>
+        <section name="main">
+            <text style="body" minLinesBefore="4" minLinesAfter="5">Paragraph</text>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	style := report.Styles.Styles[0]
+	if style.MinLinesBefore != 2 || style.MinLinesAfter != 3 {
+		t.Fatalf("expected style min lines 2 and 3, got %d and %d", style.MinLinesBefore, style.MinLinesAfter)
+	}
+	text := report.Sections.Sections[0].Elements[0].Text
+	if text.MinLinesBefore != 4 || text.MinLinesAfter != 5 {
+		t.Fatalf("expected text min lines 4 and 5, got %d and %d", text.MinLinesBefore, text.MinLinesAfter)
+	}
+}
+
+func TestParseTemplateParsesBox(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <box padding="4" radius="2" borderWidth="0.5" keepTogether="true" spacingAfter="3">
+                <fillColor r="255" g="248" b="220"/>
+                <borderColor r="200" g="160" b="0"/>
+                <text>Note</text>
+                <rowgrid columns="2"/>
+            </box>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	elem := report.Sections.Sections[0].Elements[0]
+	if elem.Type != "box" || elem.Box == nil {
+		t.Fatalf("expected box element, got %#v", elem)
+	}
+	box := elem.Box
+	if box.Padding != 4 || box.Radius != 2 || box.BorderWidth != 0.5 || !box.KeepTogether || box.SpacingAfter != 3 {
+		t.Fatalf("expected box attributes to be parsed, got %#v", box)
+	}
+	if box.FillColor == nil || box.FillColor.B != 220 || box.BorderColor == nil || box.BorderColor.R != 200 {
+		t.Fatalf("expected box colors to be parsed, got %#v and %#v", box.FillColor, box.BorderColor)
+	}
+	if len(box.Elements) != 2 || box.Elements[0].Type != "text" || box.Elements[1].Type != "rowgrid" {
+		t.Fatalf("expected box children to be parsed, got %#v", box.Elements)
+	}
+}
+
+func TestParseTemplateParsesAnyRowChild(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <row>
+                <image path="logo.png" width="30" height="15"/>
+                <keyValueList><item key="Invoice" value="42"/></keyValueList>
+                <table dataSource="{{.Items}}"/>
+                <box padding="2"><text>Note</text></box>
+                <row><text>Nested</text></row>
+            </row>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	var types []string
+	for _, elem := range report.Sections.Sections[0].Elements[0].Row.Elements {
+		types = append(types, elem.Type)
+	}
+	if got := strings.Join(types, ","); got != "image,keyValueList,table,box,row" {
+		t.Fatalf("expected every row child to be parsed, got %s", got)
+	}
+}
+
+func TestParseTemplateParsesRowGridWidths(t *testing.T) {
+	report, err := ParseTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<report xmlns="http://reportgo.io/schema/v1" version="1.0">
+    <document orientation="portrait" format="A4"/>
+    <sections>
+        <section name="main">
+            <rowgrid widths="2,1,1" gap="5" divider="true" dividerColor="#CCCCCC" dividerWidth="0.2">
+                <col width="40"/>
+                <col weight="3"/>
+                <col/>
+            </rowgrid>
+        </section>
+    </sections>
+</report>`)
+	if err != nil {
+		t.Fatalf("ParseTemplateFromString returned error: %v", err)
+	}
+
+	rowGrid := report.Sections.Sections[0].Elements[0].RowGrid
+	if rowGrid.Widths != "2,1,1" || rowGrid.Gap != 5 || !rowGrid.Divider || rowGrid.DividerColor != "#CCCCCC" || rowGrid.DividerWidth != 0.2 {
+		t.Fatalf("expected rowgrid layout attributes to be parsed, got %#v", rowGrid)
+	}
+	if len(rowGrid.Cols) != 3 || rowGrid.Cols[0].Width != 40 || rowGrid.Cols[1].Weight != 3 {
+		t.Fatalf("expected column width and weight to be parsed, got %#v", rowGrid.Cols)
+	}
+}
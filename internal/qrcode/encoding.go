@@ -0,0 +1,111 @@
+package qrcode
+
+import "strings"
+
+// alphanumericCharset lists the characters of alphanumeric mode in value
+// order.
+const alphanumericCharset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// mode is a data encoding mode with its indicator and character count
+// field widths for versions 1-9, 10-26 and 27-40.
+type mode struct {
+	indicator int
+	count     [3]int
+}
+
+var (
+	numericMode      = mode{indicator: 0x1, count: [3]int{10, 12, 14}}
+	alphanumericMode = mode{indicator: 0x2, count: [3]int{9, 11, 13}}
+	byteMode         = mode{indicator: 0x4, count: [3]int{8, 16, 16}}
+)
+
+func (m mode) countBits(version int) int {
+	switch {
+	case version <= 9:
+		return m.count[0]
+	case version <= 26:
+		return m.count[1]
+	default:
+		return m.count[2]
+	}
+}
+
+// segment is text encoded in a single mode.
+type segment struct {
+	mode  mode
+	count int
+	bits  bitBuffer
+}
+
+// makeSegment encodes text in the most compact mode that can hold all of
+// it: numeric for digits only, alphanumeric for the upper-case charset and
+// UTF-8 bytes otherwise.
+func makeSegment(text string) segment {
+	switch {
+	case text != "" && strings.Trim(text, "0123456789") == "":
+		seg := segment{mode: numericMode, count: len(text)}
+		for i := 0; i < len(text); i += 3 {
+			group := text[i:min(i+3, len(text))]
+			value := 0
+			for _, digit := range group {
+				value = value*10 + int(digit-'0')
+			}
+			seg.bits.append(value, len(group)*3+1)
+		}
+		return seg
+	case text != "" && isAlphanumeric(text):
+		seg := segment{mode: alphanumericMode, count: len(text)}
+		for i := 0; i+1 < len(text); i += 2 {
+			pair := strings.IndexByte(alphanumericCharset, text[i])*45 + strings.IndexByte(alphanumericCharset, text[i+1])
+			seg.bits.append(pair, 11)
+		}
+		if len(text)%2 == 1 {
+			seg.bits.append(strings.IndexByte(alphanumericCharset, text[len(text)-1]), 6)
+		}
+		return seg
+	default:
+		seg := segment{mode: byteMode, count: len(text)}
+		for i := 0; i < len(text); i++ {
+			seg.bits.append(int(text[i]), 8)
+		}
+		return seg
+	}
+}
+
+func isAlphanumeric(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if strings.IndexByte(alphanumericCharset, text[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bitBuffer is a sequence of bits, most significant first.
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) len() int { return len(b.bits) }
+
+// append adds the low count bits of value.
+func (b *bitBuffer) append(value, count int) {
+	for i := count - 1; i >= 0; i-- {
+		b.bits = append(b.bits, (value>>i)&1 != 0)
+	}
+}
+
+func (b *bitBuffer) appendBuffer(other bitBuffer) {
+	b.bits = append(b.bits, other.bits...)
+}
+
+// bytes packs the bits into bytes; the length must be a multiple of 8.
+func (b *bitBuffer) bytes() []byte {
+	result := make([]byte, len(b.bits)/8)
+	for i, set := range b.bits {
+		if set {
+			result[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return result
+}
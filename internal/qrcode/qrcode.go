@@ -0,0 +1,430 @@
+// Package qrcode encodes text as QR Code symbols (ISO/IEC 18004, model 2).
+//
+// The encoder picks the smallest version that holds the text at the requested
+// error correction level, using numeric, alphanumeric or byte mode for the
+// whole text, and applies the mask pattern with the lowest penalty score.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Level is an error correction level.
+type Level int
+
+// Error correction levels, recovering roughly 7%, 15%, 25% and 30% of the
+// symbol respectively.
+const (
+	Low Level = iota
+	Medium
+	Quartile
+	High
+)
+
+// ErrTooLong is returned when the text does not fit in a version 40 symbol
+// at the requested error correction level.
+var ErrTooLong = errors.New("qrcode: data too long")
+
+// ParseLevel parses "L", "M", "Q" or "H". An empty string selects Medium.
+func ParseLevel(value string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "L":
+		return Low, nil
+	case "", "M":
+		return Medium, nil
+	case "Q":
+		return Quartile, nil
+	case "H":
+		return High, nil
+	default:
+		return Medium, fmt.Errorf("qrcode: unknown error correction level %q", value)
+	}
+}
+
+// formatBits returns the two-bit level indicator used in format information.
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// Code is an encoded QR Code symbol without its quiet zone.
+type Code struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Version returns the symbol version, from 1 to 40.
+func (c *Code) Version() int { return c.version }
+
+// Size returns the number of modules along each side of the symbol.
+func (c *Code) Size() int { return c.size }
+
+// Dark reports whether the module at column x and row y is dark. Modules
+// outside the symbol are light.
+func (c *Code) Dark(x, y int) bool {
+	return x >= 0 && x < c.size && y >= 0 && y < c.size && c.modules[y][x]
+}
+
+// Encode encodes text at the given error correction level.
+func Encode(text string, level Level) (*Code, error) {
+	if level < Low || level > High {
+		return nil, fmt.Errorf("qrcode: invalid error correction level %d", level)
+	}
+
+	seg := makeSegment(text)
+	version := 0
+	for v := 1; v <= 40; v++ {
+		capacity := numDataCodewords(v, level) * 8
+		countBits := seg.mode.countBits(v)
+		if seg.count < 1<<countBits && 4+countBits+seg.bits.len() <= capacity {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	var bits bitBuffer
+	bits.append(seg.mode.indicator, 4)
+	bits.append(seg.count, seg.mode.countBits(version))
+	bits.appendBuffer(seg.bits)
+
+	capacity := numDataCodewords(version, level) * 8
+	bits.append(0, min(4, capacity-bits.len()))
+	bits.append(0, (8-bits.len()%8)%8)
+	for pad := 0xEC; bits.len() < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	code := newCode(version)
+	code.drawFunctionPatterns(level)
+	code.drawCodewords(addErrorCorrection(bits.bytes(), version, level))
+
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		code.applyMask(mask)
+		code.drawFormatBits(level, mask)
+		if penalty := code.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+		code.applyMask(mask)
+	}
+	code.applyMask(bestMask)
+	code.drawFormatBits(level, bestMask)
+
+	return code, nil
+}
+
+func newCode(version int) *Code {
+	size := version*4 + 17
+	code := &Code{version: version, size: size}
+	code.modules = make([][]bool, size)
+	code.function = make([][]bool, size)
+	for y := range code.modules {
+		code.modules[y] = make([]bool, size)
+		code.function[y] = make([]bool, size)
+	}
+	return code
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns(level Level) {
+	for i := 0; i < c.size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.size-4, 3)
+	c.drawFinder(3, c.size-4)
+
+	positions := alignmentPositions(c.version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Skip the three corners occupied by finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignment(x, y)
+		}
+	}
+
+	// Reserve the format areas; the real bits are drawn once a mask is chosen.
+	c.drawFormatBits(level, 0)
+	c.drawVersion()
+}
+
+func (c *Code) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || x >= c.size || y < 0 || y >= c.size {
+				continue
+			}
+			distance := max(abs(dx), abs(dy))
+			c.setFunction(x, y, distance != 2 && distance != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the format information and the dark
+// module next to the bottom-left finder.
+func (c *Code) drawFormatBits(level Level, mask int) {
+	bits := formatInformation(level, mask)
+
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(bits, i))
+	}
+	c.setFunction(8, 7, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.size-15+i, bit(bits, i))
+	}
+	c.setFunction(8, c.size-8, true)
+}
+
+// drawVersion draws both copies of the version information for versions 7
+// and up.
+func (c *Code) drawVersion() {
+	if c.version < 7 {
+		return
+	}
+
+	bits := versionInformation(c.version)
+	for i := 0; i < 18; i++ {
+		dark := bit(bits, i)
+		a, b := c.size-11+i%3, i/3
+		c.setFunction(a, b, dark)
+		c.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places the codewords in the zigzag column pairs from the
+// bottom-right corner, skipping function modules.
+func (c *Code) drawCodewords(data []byte) {
+	index := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vertical := 0; vertical < c.size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vertical
+				if (right+1)&2 == 0 {
+					y = c.size - 1 - vertical
+				}
+				if c.function[y][x] || index >= len(data)*8 {
+					continue
+				}
+				c.modules[y][x] = bit(int(data[index>>3]), 7-index&7)
+				index++
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by the mask pattern. Applying
+// the same mask twice restores the original modules.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if c.function[y][x] {
+				continue
+			}
+
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol with the four rules of the specification: runs
+// of five or more same-colored modules, 2x2 blocks, finder-like patterns and
+// dark/light imbalance.
+func (c *Code) penalty() int {
+	const (
+		penaltyRun     = 3
+		penaltyBlock   = 3
+		penaltyFinder  = 40
+		penaltyBalance = 10
+	)
+
+	result := 0
+	for _, vertical := range []bool{false, true} {
+		for i := 0; i < c.size; i++ {
+			var history [7]int
+			runDark := false
+			runLength := 0
+			for j := 0; j < c.size; j++ {
+				dark := c.modules[i][j]
+				if vertical {
+					dark = c.modules[j][i]
+				}
+
+				if dark == runDark {
+					runLength++
+					if runLength == 5 {
+						result += penaltyRun
+					} else if runLength > 5 {
+						result++
+					}
+					continue
+				}
+
+				c.addRunHistory(runLength, &history)
+				if !runDark {
+					result += finderPatterns(history) * penaltyFinder
+				}
+				runDark = dark
+				runLength = 1
+			}
+
+			// Close the final run against the light quiet zone.
+			if runDark {
+				c.addRunHistory(runLength, &history)
+				runLength = 0
+			}
+			c.addRunHistory(runLength+c.size, &history)
+			result += finderPatterns(history) * penaltyFinder
+		}
+	}
+
+	dark := 0
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x < c.size-1 && y < c.size-1 {
+				color := c.modules[y][x]
+				if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+					result += penaltyBlock
+				}
+			}
+		}
+	}
+
+	total := c.size * c.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * penaltyBalance
+
+	return result
+}
+
+// addRunHistory pushes a run length onto the history, treating the light
+// quiet zone before the first run as part of it.
+func (c *Code) addRunHistory(length int, history *[7]int) {
+	if history[0] == 0 {
+		length += c.size
+	}
+	copy(history[1:], history[:6])
+	history[0] = length
+}
+
+// finderPatterns counts 1:1:3:1:1 patterns with light space on either side
+// in the most recent runs.
+func finderPatterns(history [7]int) int {
+	n := history[1]
+	core := n > 0 && history[2] == n && history[3] == n*3 && history[4] == n && history[5] == n
+	count := 0
+	if core && history[0] >= n*4 && history[6] >= n {
+		count++
+	}
+	if core && history[6] >= n*4 && history[0] >= n {
+		count++
+	}
+	return count
+}
+
+// formatInformation returns the 15-bit BCH-protected format information.
+func formatInformation(level Level, mask int) int {
+	data := level.formatBits()<<3 | mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = remainder<<1 ^ (remainder>>9)*0x537
+	}
+	return (data<<10 | remainder) ^ 0x5412
+}
+
+// versionInformation returns the 18-bit BCH-protected version information.
+func versionInformation(version int) int {
+	remainder := version
+	for i := 0; i < 12; i++ {
+		remainder = remainder<<1 ^ (remainder>>11)*0x1F25
+	}
+	return version<<12 | remainder
+}
+
+// alignmentPositions returns the centers of the alignment patterns along
+// each axis, in ascending order.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	count := version/7 + 2
+	step := (version*8 + count*3 + 5) / (count*4 - 4) * 2
+	positions := make([]int, count)
+	positions[0] = 6
+	for i, position := count-1, version*4+17-7; i >= 1; i, position = i-1, position-step {
+		positions[i] = position
+	}
+	return positions
+}
+
+func bit(value, index int) bool {
+	return (value>>index)&1 != 0
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
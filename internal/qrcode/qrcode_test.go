@@ -0,0 +1,125 @@
+package qrcode
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]Level{"": Medium, "l": Low, "M": Medium, "q": Quartile, "H": High}
+	for input, want := range cases {
+		got, err := ParseLevel(input)
+		if err != nil {
+			t.Fatalf("ParseLevel(%q) returned error: %v", input, err)
+		}
+		if got != want {
+			t.Fatalf("ParseLevel(%q) = %d, want %d", input, got, want)
+		}
+	}
+
+	if _, err := ParseLevel("X"); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+}
+
+func TestDataAndErrorCorrectionCodewords(t *testing.T) {
+	seg := makeSegment("HELLO WORLD")
+	var bits bitBuffer
+	bits.append(seg.mode.indicator, 4)
+	bits.append(seg.count, seg.mode.countBits(1))
+	bits.appendBuffer(seg.bits)
+	capacity := numDataCodewords(1, Quartile) * 8
+	bits.append(0, min(4, capacity-bits.len()))
+	bits.append(0, (8-bits.len()%8)%8)
+	for pad := 0xEC; bits.len() < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	wantData := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236}
+	if got := bits.bytes(); string(got) != string(wantData) {
+		t.Fatalf("data codewords = %v, want %v", got, wantData)
+	}
+
+	wantECC := []byte{168, 72, 22, 82, 217, 54, 156, 0, 46, 15, 180, 122, 16}
+	all := addErrorCorrection(wantData, 1, Quartile)
+	if got := all[len(wantData):]; string(got) != string(wantECC) {
+		t.Fatalf("error correction codewords = %v, want %v", got, wantECC)
+	}
+}
+
+func TestFormatAndVersionInformation(t *testing.T) {
+	if got := formatInformation(Medium, 0); got != 0b101010000010010 {
+		t.Fatalf("format M/0 = %015b", got)
+	}
+	if got := formatInformation(Low, 4); got != 0b110011000101111 {
+		t.Fatalf("format L/4 = %015b", got)
+	}
+	if got := versionInformation(7); got != 0b000111110010010100 {
+		t.Fatalf("version 7 = %018b", got)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	for version, want := range map[int]int{1: 26, 7: 196, 40: 3706} {
+		if got := numRawDataModules(version) / 8; got != want {
+			t.Fatalf("raw codewords for version %d = %d, want %d", version, got, want)
+		}
+	}
+
+	code, err := Encode(strings.Repeat("a", 14), Medium)
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	if code.Version() != 1 {
+		t.Fatalf("expected 14 bytes to fit version 1-M, got version %d", code.Version())
+	}
+
+	code, err = Encode(strings.Repeat("a", 15), Medium)
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	if code.Version() != 2 {
+		t.Fatalf("expected 15 bytes to need version 2-M, got version %d", code.Version())
+	}
+
+	if _, err := Encode(strings.Repeat("a", 2953), Low); err != nil {
+		t.Fatalf("expected 2953 bytes to fit version 40-L: %v", err)
+	}
+	if _, err := Encode(strings.Repeat("a", 2954), Low); !errors.Is(err, ErrTooLong) {
+		t.Fatalf("expected ErrTooLong, got %v", err)
+	}
+}
+
+func TestEncodeFunctionPatterns(t *testing.T) {
+	code, err := Encode("https://example.com/invoice/42", High)
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	if code.Size() != code.Version()*4+17 {
+		t.Fatalf("size %d does not match version %d", code.Size(), code.Version())
+	}
+
+	// Finder pattern rings in the top-left corner.
+	for i := 0; i < 7; i++ {
+		if !code.Dark(i, 0) || !code.Dark(0, i) || !code.Dark(i, 6) || !code.Dark(6, i) {
+			t.Fatalf("expected dark outer finder ring at %d", i)
+		}
+	}
+	if code.Dark(1, 1) || code.Dark(7, 0) || !code.Dark(3, 3) {
+		t.Fatal("unexpected finder pattern interior")
+	}
+
+	// Timing pattern alternates between the finders, and the dark module is set.
+	for i := 8; i < code.Size()-8; i++ {
+		if code.Dark(i, 6) != (i%2 == 0) {
+			t.Fatalf("unexpected timing module at %d", i)
+		}
+	}
+	if !code.Dark(8, code.Size()-8) {
+		t.Fatal("expected dark module")
+	}
+	if code.Dark(-1, 0) || code.Dark(code.Size(), 0) {
+		t.Fatal("expected modules outside the symbol to be light")
+	}
+}